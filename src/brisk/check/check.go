@@ -0,0 +1,90 @@
+package check
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/kai119/Brisk/src/brisk/base"
+	"github.com/kai119/Brisk/src/checker"
+	"github.com/kai119/Brisk/src/lexer"
+	"github.com/kai119/Brisk/src/parser"
+)
+
+// CmdCheck is the implementation of the base command struct for type checking BRISK files
+var CmdCheck = &base.Command{
+	Usage: "brisk check <files>",
+	Name:  "check",
+	Short: "Check BRISK source files for type errors",
+	Long: `
+Check parses and type checks BRISK source files without running them
+the types of expressions are worked out from literals, operators, builtin
+functions and type annotations, and any operation that would fail because
+of the types it is given is reported along with its position. Syntax errors
+are also reported. Check exits with a non-zero status if any of the files
+contain errors.
+
+`,
+}
+
+func init() {
+	CmdCheck.Run = checkFiles
+}
+
+func checkFiles() {
+	if len(CmdCheck.CmdArgs) == 0 {
+		fmt.Printf("please enter a file to check.\n\n")
+		CmdCheck.PrintHelp()
+		os.Exit(1)
+	}
+
+	os.Exit(Execute(CmdCheck.CmdArgs, os.Stderr))
+}
+
+// Execute reads, parses and type checks each of the BRISK files at the specified paths.
+// Any errors are written to the specified writer and the exit status is returned
+func Execute(filenames []string, errOut io.Writer) int {
+	status := 0
+
+	for _, filename := range filenames {
+		if !checkFile(filename, errOut) {
+			status = 1
+		}
+	}
+
+	return status
+}
+
+func checkFile(filename string, errOut io.Writer) bool {
+	input, err := ioutil.ReadFile(filename)
+	if err != nil {
+		writeError(errOut, fmt.Sprintf("could not read file: %s", err))
+		return false
+	}
+
+	l := lexer.NewFile(filename, string(input))
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		for _, err := range p.Errors() {
+			writeError(errOut, err.Error())
+		}
+		return false
+	}
+
+	errors := checker.Check(program)
+	for _, err := range errors {
+		writeError(errOut, err.Error())
+	}
+
+	return len(errors) == 0
+}
+
+func writeError(out io.Writer, msg string) {
+	_, err := io.WriteString(out, msg+"\n")
+	if err != nil {
+		fmt.Printf("error writing string: %s\n", err)
+	}
+}
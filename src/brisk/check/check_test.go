@@ -0,0 +1,80 @@
+package check
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		inputs         []string
+		expectedStatus int
+		expectedOutput []string
+	}{
+		{[]string{"var a = 5; a + 5;"}, 0, nil},
+		{[]string{"var a = 5; a + true;"}, 1, []string{"1:12: type mismatch: INTEGER + BOOLEAN"}},
+		{[]string{"var = 5;"}, 1, []string{"expected next token to be IDENT, got = instead"}},
+		{
+			[]string{"len(1);", "var ok = 1;", "var f = 1; f();"},
+			1,
+			[]string{"argument to 'len' not supported, got INTEGER", "not a function: INTEGER"},
+		},
+	}
+
+	for i, tt := range tests {
+		var filenames []string
+		for _, input := range tt.inputs {
+			filename := writeTestFile(t, input)
+			defer os.Remove(filename)
+			filenames = append(filenames, filename)
+		}
+
+		var out bytes.Buffer
+		status := Execute(filenames, &out)
+
+		if status != tt.expectedStatus {
+			t.Errorf("tests[%d] - wrong exit status. expected %d, got=%d (%q)", i, tt.expectedStatus, status, out.String())
+		}
+
+		if len(tt.expectedOutput) == 0 && out.Len() != 0 {
+			t.Errorf("tests[%d] - expected no output, got=%q", i, out.String())
+		}
+
+		for _, expected := range tt.expectedOutput {
+			if !strings.Contains(out.String(), expected) {
+				t.Errorf("tests[%d] - output %q does not contain %q", i, out.String(), expected)
+			}
+		}
+	}
+}
+
+func TestExecuteMissingFile(t *testing.T) {
+	var out bytes.Buffer
+	status := Execute([]string{"does-not-exist.brisk"}, &out)
+
+	if status != 1 {
+		t.Errorf("wrong exit status. expected 1, got=%d", status)
+	}
+
+	if !strings.Contains(out.String(), "could not read file") {
+		t.Errorf("output %q does not report the missing file", out.String())
+	}
+}
+
+func writeTestFile(t *testing.T, input string) string {
+	file, err := ioutil.TempFile("", "*.brisk")
+	if err != nil {
+		t.Fatalf("could not create test file: %s", err)
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(input)
+	if err != nil {
+		t.Fatalf("could not write test file: %s", err)
+	}
+
+	return file.Name()
+}
@@ -0,0 +1,165 @@
+package format
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CONTEXT is the number of unchanged lines shown around each change in a diff
+const CONTEXT = 3
+
+// edit is a single line of a diff, which is either kept, removed or added
+type edit struct {
+	kind byte
+	text string
+}
+
+// Diff returns a unified diff that turns the original code into the formatted code, or an
+// empty string if they are the same
+func Diff(filename string, original string, formatted string) string {
+	if original == formatted {
+		return ""
+	}
+
+	edits := diffLines(splitLines(original), splitLines(formatted))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s (original)\n+++ %s (formatted)\n", filename, filename)
+
+	aLine, bLine := 0, 0
+	for i := 0; i < len(edits); {
+		if edits[i].kind == ' ' {
+			aLine++
+			bLine++
+			i++
+			continue
+		}
+
+		start := i - CONTEXT
+		if start < 0 {
+			start = 0
+		}
+		end := hunkEnd(edits, i)
+
+		// the lines of context before the change have already been counted
+		aStart, bStart := aLine-(i-start), bLine-(i-start)
+		aCount, bCount := 0, 0
+		for _, e := range edits[start:end] {
+			if e.kind != '+' {
+				aCount++
+			}
+			if e.kind != '-' {
+				bCount++
+			}
+		}
+
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aStart, aCount), hunkRange(bStart, bCount))
+		for _, e := range edits[start:end] {
+			out.WriteByte(e.kind)
+			out.WriteString(e.text)
+			if !strings.HasSuffix(e.text, "\n") {
+				out.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		for _, e := range edits[i:end] {
+			if e.kind != '+' {
+				aLine++
+			}
+			if e.kind != '-' {
+				bLine++
+			}
+		}
+		i = end
+	}
+
+	return out.String()
+}
+
+// hunkEnd finds the end of the hunk containing the change at the index. Changes that are
+// close enough together for their context to overlap are shown in the same hunk
+func hunkEnd(edits []edit, i int) int {
+	last := i
+	for j := i; j < len(edits); {
+		if edits[j].kind != ' ' {
+			last = j
+			j++
+			continue
+		}
+
+		k := j
+		for k < len(edits) && edits[k].kind == ' ' {
+			k++
+		}
+		if k == len(edits) || k-j > 2*CONTEXT {
+			break
+		}
+		j = k
+	}
+
+	end := last + 1 + CONTEXT
+	if end > len(edits) {
+		end = len(edits)
+	}
+	return end
+}
+
+func hunkRange(start int, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines finds the shortest list of edits that turns a into b, using the longest
+// common subsequence of their lines
+func diffLines(a []string, b []string) []edit {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, edit{'-', a[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, edit{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, edit{'+', b[j]})
+	}
+
+	return edits
+}
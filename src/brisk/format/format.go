@@ -0,0 +1,144 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/kai119/Brisk/src/brisk/base"
+	"github.com/kai119/Brisk/src/printer"
+)
+
+// CmdFmt is the implementation of the base command struct for formatting BRISK files
+var CmdFmt = &base.Command{
+	Usage: "brisk fmt [-w] [-d] <files>",
+	Name:  "fmt",
+	Short: "Format BRISK source files",
+	Long: `
+Fmt formats BRISK source files in the standard style
+blocks and dictionaries are broken over several lines and indented with
+four spaces, operators are surrounded by spaces and statements end with
+semicolons. Comments and single blank lines between statements are kept.
+By default the formatted code is printed. Files containing syntax errors
+are not formatted, and fmt exits with a non-zero status if any of the
+files could not be formatted.
+
+Command Arguments:
+	-w:	Write the formatted code back to the file instead of printing it
+	-d:	Print a diff of the changes instead of the formatted code
+
+`,
+}
+
+// Options decide what is done with the formatted code
+type Options struct {
+	// Write writes the formatted code back to the file it was read from
+	Write bool
+	// Diff prints a diff between the file and the formatted code
+	Diff bool
+}
+
+func init() {
+	CmdFmt.Run = formatFiles
+}
+
+func formatFiles() {
+	opts, filenames, err := parseArgs(CmdFmt.CmdArgs)
+	if err != nil {
+		fmt.Printf("could not process command line flags: %s\n\n", err)
+		CmdFmt.PrintHelp()
+		os.Exit(1)
+	}
+
+	if len(filenames) == 0 {
+		fmt.Printf("please enter a file to format.\n\n")
+		CmdFmt.PrintHelp()
+		os.Exit(1)
+	}
+
+	os.Exit(Execute(filenames, opts, os.Stdout, os.Stderr))
+}
+
+// parseArgs splits the command line arguments into the options and the files to format.
+// The flags can come before or after the files
+func parseArgs(args []string) (Options, []string, error) {
+	var opts Options
+	var filenames []string
+
+	for _, arg := range args {
+		switch {
+		case arg == "-w":
+			opts.Write = true
+		case arg == "-d":
+			opts.Diff = true
+		case strings.HasPrefix(arg, "-"):
+			return opts, nil, fmt.Errorf("command argument %s does not exist", arg)
+		default:
+			filenames = append(filenames, arg)
+		}
+	}
+
+	return opts, filenames, nil
+}
+
+// Execute formats each of the BRISK files at the specified paths. The formatted code, or the
+// diff if one was asked for, is written to out and any errors are written to errOut. The
+// exit status is returned
+func Execute(filenames []string, opts Options, out io.Writer, errOut io.Writer) int {
+	status := 0
+
+	for _, filename := range filenames {
+		if !formatFile(filename, opts, out, errOut) {
+			status = 1
+		}
+	}
+
+	return status
+}
+
+func formatFile(filename string, opts Options, out io.Writer, errOut io.Writer) bool {
+	input, err := ioutil.ReadFile(filename)
+	if err != nil {
+		writeString(errOut, fmt.Sprintf("could not read file: %s\n", err))
+		return false
+	}
+
+	formatted, err := printer.Source(filename, string(input))
+	if err != nil {
+		writeString(errOut, err.Error()+"\n")
+		return false
+	}
+
+	if opts.Diff {
+		writeString(out, Diff(filename, string(input), formatted))
+	}
+
+	if opts.Write {
+		if formatted == string(input) {
+			return true
+		}
+
+		info, err := os.Stat(filename)
+		if err != nil {
+			writeString(errOut, fmt.Sprintf("could not write file: %s\n", err))
+			return false
+		}
+		if err := ioutil.WriteFile(filename, []byte(formatted), info.Mode().Perm()); err != nil {
+			writeString(errOut, fmt.Sprintf("could not write file: %s\n", err))
+			return false
+		}
+	} else if !opts.Diff {
+		writeString(out, formatted)
+	}
+
+	return true
+}
+
+func writeString(out io.Writer, s string) {
+	_, err := io.WriteString(out, s)
+	if err != nil {
+		fmt.Printf("error writing string: %s\n", err)
+	}
+}
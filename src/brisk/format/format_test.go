@@ -0,0 +1,175 @@
+package format
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		input          string
+		opts           Options
+		expectedStatus int
+		expectedOut    string
+		expectedErr    string
+		expectedFile   string
+	}{
+		{"var a=5", Options{}, 0, "var a = 5;\n", "", "var a=5"},
+		{"var a=5", Options{Write: true}, 0, "", "", "var a = 5;\n"},
+		{"var a = 5;\n", Options{Write: true}, 0, "", "", "var a = 5;\n"},
+		{
+			"var a=5;\n",
+			Options{Diff: true},
+			0,
+			"@@ -1,1 +1,1 @@\n-var a=5;\n+var a = 5;\n",
+			"",
+			"var a=5;\n",
+		},
+		{"var a = 5;\n", Options{Diff: true}, 0, "", "", "var a = 5;\n"},
+		{
+			"var a=5;\n",
+			Options{Write: true, Diff: true},
+			0,
+			"@@ -1,1 +1,1 @@\n-var a=5;\n+var a = 5;\n",
+			"",
+			"var a = 5;\n",
+		},
+		{"var = 5;", Options{Write: true}, 1, "", "expected next token to be IDENT, got = instead", "var = 5;"},
+	}
+
+	for i, tt := range tests {
+		filename := writeTestFile(t, tt.input)
+		defer os.Remove(filename)
+
+		var out, errOut bytes.Buffer
+		status := Execute([]string{filename}, tt.opts, &out, &errOut)
+
+		if status != tt.expectedStatus {
+			t.Errorf("tests[%d] - wrong exit status. expected %d, got=%d (%q)", i, tt.expectedStatus, status, errOut.String())
+		}
+
+		if !strings.HasSuffix(out.String(), tt.expectedOut) || (tt.expectedOut == "" && out.Len() != 0) {
+			t.Errorf("tests[%d] - wrong output. expected %q, got=%q", i, tt.expectedOut, out.String())
+		}
+
+		if !strings.Contains(errOut.String(), tt.expectedErr) || (tt.expectedErr == "" && errOut.Len() != 0) {
+			t.Errorf("tests[%d] - wrong errors. expected %q, got=%q", i, tt.expectedErr, errOut.String())
+		}
+
+		content, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("could not read test file: %s", err)
+		}
+		if string(content) != tt.expectedFile {
+			t.Errorf("tests[%d] - wrong file contents. expected %q, got=%q", i, tt.expectedFile, string(content))
+		}
+	}
+}
+
+func TestExecuteMissingFile(t *testing.T) {
+	var out, errOut bytes.Buffer
+	status := Execute([]string{"does-not-exist.brisk"}, Options{}, &out, &errOut)
+
+	if status != 1 {
+		t.Errorf("wrong exit status. expected 1, got=%d", status)
+	}
+
+	if !strings.Contains(errOut.String(), "could not read file") {
+		t.Errorf("output %q does not report the missing file", errOut.String())
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		args              []string
+		expectedOpts      Options
+		expectedFilenames []string
+		expectedErr       string
+	}{
+		{[]string{"a.brisk"}, Options{}, []string{"a.brisk"}, ""},
+		{[]string{"-w", "a.brisk", "b.brisk"}, Options{Write: true}, []string{"a.brisk", "b.brisk"}, ""},
+		{[]string{"a.brisk", "-d", "-w"}, Options{Write: true, Diff: true}, []string{"a.brisk"}, ""},
+		{[]string{"-x", "a.brisk"}, Options{}, nil, "command argument -x does not exist"},
+	}
+
+	for _, tt := range tests {
+		opts, filenames, err := parseArgs(tt.args)
+
+		if tt.expectedErr != "" {
+			if err == nil || err.Error() != tt.expectedErr {
+				t.Errorf("wrong error for %q. expected=%q, got=%v", tt.args, tt.expectedErr, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("unexpected error for %q: %s", tt.args, err)
+		}
+		if opts != tt.expectedOpts {
+			t.Errorf("wrong options for %q. expected=%+v, got=%+v", tt.args, tt.expectedOpts, opts)
+		}
+		if !reflect.DeepEqual(filenames, tt.expectedFilenames) {
+			t.Errorf("wrong files for %q. expected=%q, got=%q", tt.args, tt.expectedFilenames, filenames)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		original  string
+		formatted string
+		expected  string
+	}{
+		{"a\n", "a\n", ""},
+		{
+			"1\n2\n3\n4\n5\nx\n6\n7\n8\n9\n10\n11\n12\ny\n13\n",
+			"1\n2\n3\n4\n5\nX\n6\n7\n8\n9\n10\n11\n12\nY\n13\n",
+			"--- f (original)\n+++ f (formatted)\n" +
+				"@@ -3,7 +3,7 @@\n 3\n 4\n 5\n-x\n+X\n 6\n 7\n 8\n" +
+				"@@ -11,5 +11,5 @@\n 10\n 11\n 12\n-y\n+Y\n 13\n",
+		},
+		{
+			"1\n2\nx\n3\n4\n5\n6\n7\ny\n",
+			"1\n2\n3\n4\n5\n6\n7\nz\n",
+			"--- f (original)\n+++ f (formatted)\n" +
+				"@@ -1,9 +1,8 @@\n 1\n 2\n-x\n 3\n 4\n 5\n 6\n 7\n-y\n+z\n",
+		},
+		{
+			"a\nb",
+			"a\nb\n",
+			"--- f (original)\n+++ f (formatted)\n" +
+				"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+		{
+			"",
+			"a\n",
+			"--- f (original)\n+++ f (formatted)\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+	}
+
+	for _, tt := range tests {
+		diff := Diff("f", tt.original, tt.formatted)
+		if diff != tt.expected {
+			t.Errorf("wrong diff for %q.\nexpected=%q\ngot=%q", tt.original, tt.expected, diff)
+		}
+	}
+}
+
+func writeTestFile(t *testing.T, input string) string {
+	file, err := ioutil.TempFile("", "*.brisk")
+	if err != nil {
+		t.Fatalf("could not create test file: %s", err)
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(input)
+	if err != nil {
+		t.Fatalf("could not write test file: %s", err)
+	}
+
+	return file.Name()
+}
@@ -5,12 +5,18 @@ import (
 	"os"
 
 	"github.com/kai119/Brisk/src/brisk/base"
+	"github.com/kai119/Brisk/src/brisk/check"
+	"github.com/kai119/Brisk/src/brisk/format"
 	"github.com/kai119/Brisk/src/brisk/repl"
+	"github.com/kai119/Brisk/src/brisk/run"
 )
 
 func init() {
 	base.Brisk.Commands = []*base.Command{
 		repl.CmdRepl,
+		run.CmdRun,
+		check.CmdCheck,
+		format.CmdFmt,
 	}
 }
 
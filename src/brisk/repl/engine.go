@@ -0,0 +1,66 @@
+package repl
+
+import (
+	"fmt"
+
+	"github.com/kai119/Brisk/src/compiler"
+	"github.com/kai119/Brisk/src/evaluator"
+	"github.com/kai119/Brisk/src/evaluator/object"
+	"github.com/kai119/Brisk/src/parser/ast"
+	"github.com/kai119/Brisk/src/vm"
+)
+
+// The engines that the REPL can run programs with
+const (
+	EVAL_ENGINE = "eval"
+	VM_ENGINE   = "vm"
+)
+
+// engine runs each line of the REPL, keeping the variables declared by earlier lines
+type engine interface {
+	run(program *ast.Program) object.Object
+}
+
+// newEngine creates the engine with the name given to the -e flag
+func newEngine(name string) (engine, error) {
+	switch name {
+	case EVAL_ENGINE:
+		return &evalEngine{env: object.NewEnvironment()}, nil
+	case VM_ENGINE:
+		return &vmEngine{symbols: compiler.NewSymbolTable(), constants: []object.Object{}}, nil
+	default:
+		return nil, fmt.Errorf("unknown engine %s, expected %s or %s", name, EVAL_ENGINE, VM_ENGINE)
+	}
+}
+
+// evalEngine runs programs with the tree-walking evaluator
+type evalEngine struct {
+	env *object.Environment
+}
+
+func (e *evalEngine) run(program *ast.Program) object.Object {
+	return evaluator.Eval(program, e.env)
+}
+
+// vmEngine compiles programs to bytecode and runs them on the virtual machine
+type vmEngine struct {
+	symbols   *compiler.SymbolTable
+	constants []object.Object
+	globals   []object.Object
+}
+
+func (e *vmEngine) run(program *ast.Program) object.Object {
+	c := compiler.NewWithState(e.symbols, e.constants)
+	err := c.Compile(program)
+	bytecode := c.Bytecode()
+	// constants added before the error are kept, since functions already compiled may use them
+	e.constants = bytecode.Constants
+	if err != nil {
+		return &object.Error{Message: err.Error()}
+	}
+
+	machine := vm.NewWithGlobals(bytecode, e.globals)
+	result := machine.Run()
+	e.globals = machine.Globals()
+	return result
+}
@@ -8,8 +8,6 @@ import (
 	"strings"
 
 	"github.com/kai119/Brisk/src/brisk/base"
-	"github.com/kai119/Brisk/src/evaluator"
-	"github.com/kai119/Brisk/src/evaluator/object"
 	"github.com/kai119/Brisk/src/lexer"
 	"github.com/kai119/Brisk/src/lexer/token"
 	"github.com/kai119/Brisk/src/parser"
@@ -23,7 +21,7 @@ const (
 
 // CmdRepl is the implementation of the base command struct for the REPL
 var CmdRepl = &base.Command{
-	Usage: "brisk repl [-c command list [-i]]",
+	Usage: "brisk repl [-c command list [-i]] [-e engine]",
 	Name:  "repl",
 	Short: "Start the BRISK REPL",
 	Long: `
@@ -36,6 +34,9 @@ To exit the REPL, type "exit" or press Ctl+C
 Command Arguments:
 	-c [command list]:	A list of commands to run inside of the repl
 	-i:	REPL won't exit after -c commands are processed
+	-e [engine]:	The engine that runs each line, either "eval" to use the
+		tree-walking evaluator (the default) or "vm" to compile the
+		line to bytecode and run it on the virtual machine
 
 `,
 }
@@ -44,6 +45,7 @@ func init() {
 	CmdRepl.ArgsList = map[string]bool{
 		"-c": true,
 		"-i": false,
+		"-e": true,
 	}
 	CmdRepl.Run = runRepl
 }
@@ -65,8 +67,13 @@ func Start(in io.Reader, out io.Writer) {
 		os.Exit(0)
 	}
 
+	e, err := findEngine(CmdRepl.Args)
+	if err != nil {
+		fmt.Printf("%s\n", err)
+		return
+	}
+
 	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
 
 	for {
 		fmt.Print(PROMPT)
@@ -81,7 +88,7 @@ func Start(in io.Reader, out io.Writer) {
 			continue
 		}
 
-		evaluated := evaluator.Eval(program, env)
+		evaluated := e.run(program)
 		if evaluated != nil {
 			_, err := io.WriteString(out, evaluated.Inspect())
 			if err != nil {
@@ -123,6 +130,18 @@ func processCommands(args map[string][]string) ([]token.Token, bool) {
 	return tokens, isContinuing
 }
 
+// findEngine creates the engine named by the -e flag, using the evaluator if no engine is given
+func findEngine(args map[string][]string) (engine, error) {
+	names := args["-e"]
+	if names == nil {
+		return newEngine(EVAL_ENGINE)
+	}
+	if len(names) != 1 {
+		return nil, fmt.Errorf("-e flag takes exactly one engine, got %d", len(names))
+	}
+	return newEngine(names[0])
+}
+
 func createParser(scanner *bufio.Scanner) (*parser.Parser, bool) {
 	scanned := scanner.Scan()
 	if !scanned {
@@ -159,9 +178,9 @@ func createParser(scanner *bufio.Scanner) (*parser.Parser, bool) {
 	return parser.New(l), true
 }
 
-func printParserErrors(out io.Writer, errors []string) {
-	for _, msg := range errors {
-		_, err := io.WriteString(out, "\t"+msg+"\n")
+func printParserErrors(out io.Writer, errors []*parser.ParseError) {
+	for _, parseErr := range errors {
+		_, err := io.WriteString(out, "\t"+parseErr.Error()+"\n")
 		if err != nil {
 			fmt.Printf("error writing string: %s\n", err)
 		}
@@ -3,6 +3,9 @@ package repl
 import (
 	"reflect"
 	"testing"
+
+	"github.com/kai119/Brisk/src/lexer"
+	"github.com/kai119/Brisk/src/parser"
 )
 
 func TestReplFindArgs(t *testing.T) {
@@ -107,3 +110,45 @@ func TestReplFindArgsFail(t *testing.T) {
 		}
 	}
 }
+
+func TestReplEngines(t *testing.T) {
+	tests := []struct {
+		args     map[string][]string
+		expected string
+		err      string
+	}{
+		{map[string][]string{}, "ERROR: 1:5: identifier not found: y", ""},
+		{map[string][]string{"-e": {"eval"}}, "ERROR: 1:5: identifier not found: y", ""},
+		{map[string][]string{"-e": {"vm"}}, "ERROR: 1:5: identifier not found: y", ""},
+		{map[string][]string{"-e": {"jit"}}, "", "unknown engine jit, expected eval or vm"},
+		{map[string][]string{"-e": {"eval", "vm"}}, "", "-e flag takes exactly one engine, got 2"},
+	}
+
+	for i, tt := range tests {
+		e, err := findEngine(tt.args)
+		if tt.err != "" {
+			if err == nil || err.Error() != tt.err {
+				t.Fatalf("tests[%d] - expected error %q, got %v", i, tt.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("tests[%d] - received the following error when finding the engine: %s", i, err)
+		}
+
+		lines := []string{"var x = 2;", "func double(n) { n * 2 }", "double(x)", "x + y;"}
+		results := []string{}
+		for _, line := range lines {
+			p := parser.New(lexer.New(line))
+			result := e.run(p.ParseProgram())
+			if result != nil {
+				results = append(results, result.Inspect())
+			}
+		}
+
+		expected := []string{"4", tt.expected}
+		if !reflect.DeepEqual(expected, results) {
+			t.Fatalf("tests[%d] - results %q do not equal expected %q", i, results, expected)
+		}
+	}
+}
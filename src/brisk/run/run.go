@@ -0,0 +1,163 @@
+package run
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kai119/Brisk/src/brisk/base"
+	"github.com/kai119/Brisk/src/evaluator"
+	"github.com/kai119/Brisk/src/evaluator/object"
+	"github.com/kai119/Brisk/src/lexer"
+	"github.com/kai119/Brisk/src/optimizer"
+	"github.com/kai119/Brisk/src/parser"
+)
+
+// ARGS is the name of the identifier that the command line arguments are bound to
+// inside of the program being run
+const ARGS = "args"
+
+// CmdRun is the implementation of the base command struct for running BRISK files
+var CmdRun = &base.Command{
+	Usage: "brisk run [-O] [-max-depth <n>] <file.brisk> [arguments]",
+	Name:  "run",
+	Short: "Run a BRISK source file",
+	Long: `
+Run executes a BRISK source file
+the file is parsed in full before it is run, and any syntax
+errors are reported without running any of the program. Any
+arguments given after the file name are passed into the program
+as an array of strings bound to the identifier "args".
+Run exits with a non-zero status if the program fails.
+
+Command Arguments:
+	-O:	Optimize the program before running it. Constant
+		arithmetic is worked out once, branches and statements
+		that can never run are removed, and constant expressions
+		that would fail, such as 1 / 0, in code that can run
+		are reported without running any of the program
+	-max-depth:	The most function calls that can be running
+		at once, 10000 by default. A program that recurses
+		any deeper fails with a maximum call depth error
+
+`,
+}
+
+// Options decide how the file is run
+type Options struct {
+	// Optimize simplifies the program with the optimizer before it is run
+	Optimize bool
+	// MaxCallDepth is the most function calls that can be running at once, or zero to use the
+	// evaluator's default
+	MaxCallDepth int
+}
+
+func init() {
+	CmdRun.Run = runFile
+}
+
+func runFile() {
+	opts, args, err := parseArgs(CmdRun.CmdArgs)
+	if err != nil {
+		fmt.Printf("could not process command line flags: %s\n\n", err)
+		CmdRun.PrintHelp()
+		os.Exit(1)
+	}
+
+	if len(args) == 0 {
+		fmt.Printf("please enter a file to run.\n\n")
+		CmdRun.PrintHelp()
+		os.Exit(1)
+	}
+
+	os.Exit(Execute(args[0], args[1:], opts, os.Stderr))
+}
+
+// parseArgs splits the command line arguments into the options and the file to run followed
+// by its arguments. The flags have to come before the file, since everything after the file
+// is passed into the program
+func parseArgs(args []string) (Options, []string, error) {
+	var opts Options
+
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "-O":
+			opts.Optimize = true
+		case "-max-depth":
+			if len(args) < 2 {
+				return opts, nil, fmt.Errorf("-max-depth flag takes a number of calls")
+			}
+			depth, err := strconv.Atoi(args[1])
+			if err != nil || depth < 1 {
+				return opts, nil, fmt.Errorf("-max-depth flag takes a positive number of calls, got %s", args[1])
+			}
+			opts.MaxCallDepth = depth
+			args = args[1:]
+		default:
+			return opts, nil, fmt.Errorf("command argument %s does not exist", args[0])
+		}
+		args = args[1:]
+	}
+
+	return opts, args, nil
+}
+
+// Execute reads, parses and evaluates the BRISK file at the specified path, binding the
+// arguments to the program's args array. Any errors are written to the specified writer
+// and the exit status of the program is returned
+func Execute(filename string, args []string, opts Options, errOut io.Writer) int {
+	input, err := ioutil.ReadFile(filename)
+	if err != nil {
+		writeError(errOut, fmt.Sprintf("could not read file: %s", err))
+		return 1
+	}
+
+	l := lexer.NewFile(filename, string(input))
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		for _, err := range p.Errors() {
+			writeError(errOut, err.Error())
+		}
+		return 1
+	}
+
+	if opts.Optimize {
+		if errors := optimizer.Optimize(program); len(errors) != 0 {
+			for _, err := range errors {
+				writeError(errOut, err.Error())
+			}
+			return 1
+		}
+	}
+
+	env := object.NewEnvironment()
+	env.Set(ARGS, argsToArray(args))
+
+	evaluated := evaluator.New(evaluator.Options{MaxCallDepth: opts.MaxCallDepth}).Eval(program, env)
+	if errObj, ok := evaluated.(*object.Error); ok {
+		writeError(errOut, errObj.String())
+		return 1
+	}
+
+	return 0
+}
+
+func argsToArray(args []string) *object.Array {
+	elements := make([]object.Object, len(args))
+	for i, arg := range args {
+		elements[i] = &object.String{Value: arg}
+	}
+	return &object.Array{Elements: elements}
+}
+
+func writeError(out io.Writer, msg string) {
+	_, err := io.WriteString(out, msg+"\n")
+	if err != nil {
+		fmt.Printf("error writing string: %s\n", err)
+	}
+}
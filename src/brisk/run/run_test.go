@@ -0,0 +1,163 @@
+package run
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		input          string
+		args           []string
+		expectedStatus int
+		expectedOutput string
+	}{
+		{"var a = 5; a + 5;", nil, 0, ""},
+		{`if (len(args) != 2) { 5 + true; }`, []string{"one", "two"}, 0, ""},
+		{`if (args[0] != "one") { 5 + true; }`, []string{"one"}, 0, ""},
+		{"5 + true;", nil, 1, "type mismatch: INTEGER + BOOLEAN"},
+		{"var = 5;", nil, 1, "expected next token to be IDENT, got = instead"},
+	}
+
+	for i, tt := range tests {
+		filename := writeTestFile(t, tt.input)
+		defer os.Remove(filename)
+
+		var out bytes.Buffer
+		status := Execute(filename, tt.args, Options{}, &out)
+
+		if status != tt.expectedStatus {
+			t.Errorf("tests[%d] - wrong exit status. expected %d, got=%d (%q)", i, tt.expectedStatus, status, out.String())
+		}
+
+		if tt.expectedOutput == "" && out.Len() != 0 {
+			t.Errorf("tests[%d] - expected no output, got=%q", i, out.String())
+		}
+
+		if !strings.Contains(out.String(), tt.expectedOutput) {
+			t.Errorf("tests[%d] - output %q does not contain %q", i, out.String(), tt.expectedOutput)
+		}
+	}
+}
+
+func TestExecuteOptimized(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedStatus int
+		expectedOutput string
+	}{
+		{"var day = 60 * 60 * 24; if (day != 86400) { [][0] + 1; }", 0, ""},
+		{"var f = func() { 10 / 0; };", 1, "1:18: division by zero"},
+		{"if (false) { 1 + true; }; 2 % 0;", 1, "1:27: modulo by zero\n"},
+		{"if (false) { println(1 / 0); }; return 0; 1 + true;", 0, ""},
+	}
+
+	for i, tt := range tests {
+		filename := writeTestFile(t, tt.input)
+		defer os.Remove(filename)
+
+		var out bytes.Buffer
+		status := Execute(filename, nil, Options{Optimize: true}, &out)
+
+		if status != tt.expectedStatus {
+			t.Errorf("tests[%d] - wrong exit status. expected %d, got=%d (%q)", i, tt.expectedStatus, status, out.String())
+		}
+
+		if tt.expectedOutput == "" && out.Len() != 0 {
+			t.Errorf("tests[%d] - expected no output, got=%q", i, out.String())
+		}
+
+		if !strings.Contains(out.String(), tt.expectedOutput) {
+			t.Errorf("tests[%d] - output %q does not contain %q", i, out.String(), tt.expectedOutput)
+		}
+	}
+}
+
+func TestExecuteMaxCallDepth(t *testing.T) {
+	filename := writeTestFile(t, "func count(n) { if (n == 0) { return 0 } 1 + count(n - 1) }\ncount(20);")
+	defer os.Remove(filename)
+
+	var out bytes.Buffer
+	if status := Execute(filename, nil, Options{}, &out); status != 0 {
+		t.Fatalf("wrong exit status. expected 0, got=%d (%q)", status, out.String())
+	}
+
+	status := Execute(filename, nil, Options{MaxCallDepth: 10}, &out)
+	if status != 1 {
+		t.Errorf("wrong exit status. expected 1, got=%d", status)
+	}
+
+	expected := ".brisk:1:46: maximum call depth 10 exceeded\n    at count ("
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("output %q does not contain %q", out.String(), expected)
+	}
+
+	out.Reset()
+	if status := Execute(filename, nil, Options{}, &out); status != 0 {
+		t.Errorf("maximum call depth was kept after the run. got status=%d (%q)", status, out.String())
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		args         []string
+		expectedOpts Options
+		expectedArgs []string
+		expectedErr  string
+	}{
+		{[]string{"file.brisk", "-O"}, Options{}, []string{"file.brisk", "-O"}, ""},
+		{[]string{"-O", "file.brisk", "one"}, Options{Optimize: true}, []string{"file.brisk", "one"}, ""},
+		{[]string{"-max-depth", "50", "-O", "file.brisk"}, Options{Optimize: true, MaxCallDepth: 50}, []string{"file.brisk"}, ""},
+		{[]string{"-x", "file.brisk"}, Options{}, nil, "command argument -x does not exist"},
+		{[]string{"-max-depth"}, Options{}, nil, "-max-depth flag takes a number of calls"},
+		{[]string{"-max-depth", "0", "file.brisk"}, Options{}, nil, "-max-depth flag takes a positive number of calls, got 0"},
+	}
+
+	for i, tt := range tests {
+		opts, args, err := parseArgs(tt.args)
+		if tt.expectedErr != "" {
+			if err == nil || err.Error() != tt.expectedErr {
+				t.Errorf("tests[%d] - expected error %q, got=%v", i, tt.expectedErr, err)
+			}
+			continue
+		}
+
+		if opts != tt.expectedOpts {
+			t.Errorf("tests[%d] - wrong options. expected=%+v, got=%+v", i, tt.expectedOpts, opts)
+		}
+		if strings.Join(args, " ") != strings.Join(tt.expectedArgs, " ") {
+			t.Errorf("tests[%d] - wrong arguments. expected=%q, got=%q", i, tt.expectedArgs, args)
+		}
+	}
+}
+
+func TestExecuteMissingFile(t *testing.T) {
+	var out bytes.Buffer
+	status := Execute("does-not-exist.brisk", nil, Options{}, &out)
+
+	if status != 1 {
+		t.Errorf("wrong exit status. expected 1, got=%d", status)
+	}
+
+	if !strings.Contains(out.String(), "could not read file") {
+		t.Errorf("output %q does not report the missing file", out.String())
+	}
+}
+
+func writeTestFile(t *testing.T, input string) string {
+	file, err := ioutil.TempFile("", "*.brisk")
+	if err != nil {
+		t.Fatalf("could not create test file: %s", err)
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(input)
+	if err != nil {
+		t.Fatalf("could not write test file: %s", err)
+	}
+
+	return file.Name()
+}
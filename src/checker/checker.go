@@ -0,0 +1,516 @@
+package checker
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kai119/Brisk/src/evaluator/object"
+	"github.com/kai119/Brisk/src/lexer/token"
+	"github.com/kai119/Brisk/src/parser/ast"
+)
+
+// Error is a type error found by the checker, along with the position in the source code
+// where it was found
+type Error struct {
+	Pos     token.Position
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Pos.IsValid() {
+		return e.Pos.String() + ": " + e.Message
+	}
+	return e.Message
+}
+
+// Checker infers the types of the expressions in a program without running it, so that
+// type errors can be found before the line they are on is executed. Only errors that would
+// definitely happen at runtime are reported, so anything the checker can't infer, such as
+// untyped parameters or the elements of arrays, is given an unknown type and not checked
+type Checker struct {
+	errors []*Error
+
+	// returns holds the types returned by the return statements of each function that is
+	// currently being checked, with the innermost function last
+	returns [][]returnType
+}
+
+type returnType struct {
+	pos token.Position
+	t   Type
+}
+
+// New creates a new type checker
+func New() *Checker {
+	return &Checker{}
+}
+
+// Check type checks a program, returning all of the errors it finds sorted by their position
+func Check(program *ast.Program) []*Error {
+	c := New()
+	c.Check(program)
+	return c.Errors()
+}
+
+// Check type checks a program. The errors it finds are added to the checker's errors
+func (c *Checker) Check(program *ast.Program) {
+	c.checkStatements(program.Statements, newScope(nil, program))
+}
+
+// Errors returns all errors that the checker has found, sorted by their position
+func (c *Checker) Errors() []*Error {
+	sort.SliceStable(c.errors, func(i, j int) bool {
+		a, b := c.errors[i].Pos, c.errors[j].Pos
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return c.errors
+}
+
+func (c *Checker) addError(pos token.Position, format string, a ...interface{}) {
+	c.errors = append(c.errors, &Error{Pos: pos, Message: fmt.Sprintf(format, a...)})
+}
+
+// checkStatements checks a list of statements, returning the type of the value that they
+// result in. Named functions are declared before any of the statements are checked, in the
+// same way that the evaluator hoists them
+func (c *Checker) checkStatements(statements []ast.Statement, s *scope) Type {
+	for _, statement := range statements {
+		if fs, ok := statement.(*ast.FunctionStatement); ok {
+			s.set(fs.Name.Value, Type{Name: object.FUNCTION_OBJ, Signature: newSignature(fs.Function)})
+		}
+	}
+
+	result := Null
+	for _, statement := range statements {
+		result = c.checkStatement(statement, s)
+		if result == terminated {
+			return terminated
+		}
+	}
+
+	return result
+}
+
+func (c *Checker) checkStatement(statement ast.Statement, s *scope) Type {
+	switch statement := statement.(type) {
+	case *ast.ExpressionStatement:
+		return c.checkExpression(statement.Expression, s)
+	case *ast.VarStatement:
+		t := c.checkExpression(statement.Value, s)
+		if statement.Destructure != ast.NoDestructure {
+			c.checkDestructure(statement, t, s)
+			return Null
+		}
+		if statement.Type != nil {
+			want := fromAnnotation(statement.Type)
+			if t.IsKnown() && t.Name != want.Name {
+				c.addError(statement.Pos(), "wrong type for variable %s: want=%s, got=%s", statement.Name.Value, statement.Type, t)
+			}
+			t = want
+		}
+		s.set(statement.Name.Value, t)
+		return Null
+	case *ast.ReturnStatement:
+		t := c.checkExpression(statement.ReturnValue, s)
+		if len(c.returns) > 0 {
+			last := len(c.returns) - 1
+			c.returns[last] = append(c.returns[last], returnType{pos: statement.Pos(), t: t})
+		}
+		return terminated
+	case *ast.FunctionStatement:
+		if t, ok := s.get(statement.Name.Value); ok && t.Signature != nil {
+			c.checkFunctionBody(statement.Function, t.Signature, s)
+		}
+		return Null
+	case *ast.WhileStatement:
+		if statement.Body != nil {
+			s.forgetAssigned(statement.Body)
+		}
+		c.checkExpression(statement.Condition, s)
+		c.checkBlock(statement.Body, s)
+		return Null
+	case *ast.ForStatement:
+		loopScope := newScope(s, statement)
+		if statement.Init != nil {
+			c.checkStatement(statement.Init, loopScope)
+		}
+		if statement.Body != nil {
+			loopScope.forgetAssigned(statement.Body)
+		}
+		if statement.Post != nil {
+			loopScope.forgetAssigned(statement.Post)
+		}
+		if statement.Condition != nil {
+			c.checkExpression(statement.Condition, loopScope)
+		}
+		c.checkBlock(statement.Body, loopScope)
+		if statement.Post != nil {
+			c.checkStatement(statement.Post, loopScope)
+		}
+		return Null
+	case *ast.ForInStatement:
+		c.checkForInStatement(statement, s)
+		return Null
+	default:
+		return Unknown
+	}
+}
+
+// checkDestructure checks that a destructuring declaration's value can be unpacked in the way
+// that the declaration asks for. The types of the values inside aren't known, so all of the
+// variables are declared with an unknown type
+func (c *Checker) checkDestructure(statement *ast.VarStatement, t Type, s *scope) {
+	if t.IsKnown() {
+		switch {
+		case statement.Destructure == ast.TupleDestructure && t != Tuple:
+			c.addError(statement.Pos(), "wrong number of values to unpack: want=%d, got=1", len(statement.Names))
+		case statement.Destructure == ast.ArrayDestructure && t != Array:
+			c.addError(statement.Pos(), "cannot destructure %s as an array", t)
+		case statement.Destructure == ast.DictionaryDestructure && t != Dictionary:
+			c.addError(statement.Pos(), "cannot destructure %s as a dictionary", t)
+		}
+	}
+
+	for _, name := range statement.Names {
+		s.set(name.Value, Unknown)
+	}
+}
+
+func (c *Checker) checkBlock(block *ast.BlockStatement, s *scope) Type {
+	if block == nil {
+		return Null
+	}
+	return c.checkStatements(block.Statements, s)
+}
+
+func (c *Checker) checkForInStatement(fi *ast.ForInStatement, s *scope) {
+	iterable := c.checkExpression(fi.Iterable, s)
+
+	element := Unknown
+	switch {
+	case iterable == String:
+		element = Char
+	case iterable.IsKnown() && iterable != Array && iterable != Dictionary:
+		c.addError(fi.Iterable.Pos(), "cannot iterate over %s", iterable)
+	}
+
+	loopScope := newScope(s, fi.Body)
+	loopScope.set(fi.Variable.Value, element)
+	if fi.Body != nil {
+		loopScope.forgetAssigned(fi.Body)
+	}
+	c.checkBlock(fi.Body, loopScope)
+}
+
+func (c *Checker) checkExpression(exp ast.Expression, s *scope) Type {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return Integer
+	case *ast.FloatLiteral:
+		return Float
+	case *ast.StringLiteral:
+		return String
+	case *ast.CharLiteral:
+		return Char
+	case *ast.Boolean:
+		return Boolean
+	case *ast.Identifier:
+		if t, ok := s.lookup(exp.Value); ok {
+			return t
+		}
+		if _, ok := builtins[exp.Value]; ok {
+			return Type{Name: object.BUILTIN_OBJ, Builtin: exp.Value}
+		}
+		return Unknown
+	case *ast.PrefixExpression:
+		return c.checkPrefixExpression(exp, s)
+	case *ast.InfixExpression:
+		left := c.checkExpression(exp.Left, s)
+		right := c.checkExpression(exp.Right, s)
+		if exp.Token.Type == token.LOGICAL_AND || exp.Token.Type == token.LOGICAL_OR {
+			return Boolean
+		}
+		return c.checkInfix(exp.Pos(), exp.Operator, left, right)
+	case *ast.IfExpression:
+		c.checkExpression(exp.Condition, s)
+		consequence := c.checkBlock(exp.Consequence, s)
+		if exp.Alternative == nil {
+			// the result is null whenever the condition is false, which can't be known
+			// until the program runs
+			return Unknown
+		}
+		alternative := c.checkBlock(exp.Alternative, s)
+		return union(consequence, alternative)
+	case *ast.FunctionLiteral:
+		signature := newSignature(exp)
+		c.checkFunctionBody(exp, signature, s)
+		return Type{Name: object.FUNCTION_OBJ, Signature: signature}
+	case *ast.CallExpression:
+		return c.checkCallExpression(exp, s)
+	case *ast.ArrayLiteral:
+		for _, element := range exp.Elements {
+			c.checkExpression(element, s)
+		}
+		return Array
+	case *ast.TupleLiteral:
+		for _, element := range exp.Elements {
+			c.checkExpression(element, s)
+		}
+		return Tuple
+	case *ast.DictionaryLiteral:
+		for key, value := range exp.Pairs {
+			keyType := c.checkExpression(key, s)
+			if keyType.IsKnown() && !isHashable(keyType) {
+				c.addError(key.Pos(), "unusable as hash key: %s", keyType)
+			}
+			c.checkExpression(value, s)
+		}
+		return Dictionary
+	case *ast.IndexExpression:
+		left := c.checkExpression(exp.Left, s)
+		index := c.checkExpression(exp.Index, s)
+		return c.checkIndex(exp.Pos(), left, index)
+	case *ast.AssignExpression:
+		return c.checkAssignExpression(exp, s)
+	default:
+		return Unknown
+	}
+}
+
+func (c *Checker) checkPrefixExpression(exp *ast.PrefixExpression, s *scope) Type {
+	right := c.checkExpression(exp.Right, s)
+
+	switch exp.Operator {
+	case "!":
+		return Boolean
+	case "-":
+		if !right.IsKnown() || isNumber(right) {
+			return right
+		}
+		c.addError(exp.Pos(), "unknown operator: -%s", right)
+		return Unknown
+	default:
+		return Unknown
+	}
+}
+
+// checkInfix works out the type of an infix expression following the same rules as the
+// evaluator, reporting an error if the evaluator would fail on the types given
+func (c *Checker) checkInfix(pos token.Position, operator string, left, right Type) Type {
+	isComparison := false
+	switch operator {
+	case "==", "!=", "<", ">", "<=", ">=":
+		isComparison = true
+	}
+
+	if !left.IsKnown() || !right.IsKnown() {
+		if isComparison {
+			return Boolean
+		}
+		return Unknown
+	}
+
+	isStringLike := func(t Type) bool { return t == String || t == Char }
+
+	switch {
+	case left == Integer && right == Integer:
+		if isComparison {
+			return Boolean
+		}
+		return Integer
+	case isNumber(left) && isNumber(right):
+		if isComparison {
+			return Boolean
+		}
+		return Float
+	case isStringLike(left) && isStringLike(right):
+		if isComparison {
+			return Boolean
+		}
+		if operator == "+" {
+			return String
+		}
+		if left == Char && right == Char {
+			c.addError(pos, "unknown operator: %s %s %s", left, operator, right)
+		} else {
+			c.addError(pos, "unknown operator %s %s %s", String, operator, String)
+		}
+		return Unknown
+	case left == String && right == Integer, left == Integer && right == String:
+		if operator == "*" {
+			return String
+		}
+		c.addError(pos, "unknown operator %s %s %s", String, operator, Integer)
+		return Unknown
+	case operator == "==" || operator == "!=":
+		return Boolean
+	case left.Name != right.Name:
+		c.addError(pos, "type mismatch: %s %s %s", left, operator, right)
+		return Unknown
+	default:
+		c.addError(pos, "unknown operator: %s %s %s", left, operator, right)
+		return Unknown
+	}
+}
+
+func (c *Checker) checkIndex(pos token.Position, left, index Type) Type {
+	switch {
+	case left == Dictionary:
+		if index.IsKnown() && !isHashable(index) {
+			c.addError(pos, "unusable as hash key: %s", index)
+		}
+		return Unknown
+	case left == String && index == Integer:
+		return Char
+	case !left.IsKnown() || !index.IsKnown():
+		return Unknown
+	case left == Array && index == Integer:
+		return Unknown
+	default:
+		c.addError(pos, "index operator not supported: %s", left)
+		return Unknown
+	}
+}
+
+func (c *Checker) checkAssignExpression(exp *ast.AssignExpression, s *scope) Type {
+	value := c.checkExpression(exp.Value, s)
+
+	switch target := exp.Target.(type) {
+	case *ast.Identifier:
+		if exp.Operator != "=" {
+			current := c.checkExpression(target, s)
+			value = c.checkInfix(exp.Pos(), strings.TrimSuffix(exp.Operator, "="), current, value)
+		}
+		s.assign(target.Value, value)
+		return value
+	case *ast.IndexExpression:
+		left := c.checkExpression(target.Left, s)
+		index := c.checkExpression(target.Index, s)
+
+		if exp.Operator != "=" {
+			current := c.checkIndex(target.Pos(), left, index)
+			value = c.checkInfix(exp.Pos(), strings.TrimSuffix(exp.Operator, "="), current, value)
+		}
+
+		switch {
+		case left == Array && index.IsKnown() && index != Integer:
+			c.addError(target.Index.Pos(), "array index must be INTEGER, got %s", index)
+		case left == Dictionary && index.IsKnown() && !isHashable(index):
+			c.addError(target.Index.Pos(), "unusable as hash key: %s", index)
+		}
+		return value
+	default:
+		return Unknown
+	}
+}
+
+func (c *Checker) checkCallExpression(exp *ast.CallExpression, s *scope) Type {
+	function := c.checkExpression(exp.Function, s)
+
+	args := make([]Type, len(exp.Arguments))
+	for i, arg := range exp.Arguments {
+		args[i] = c.checkExpression(arg, s)
+	}
+
+	switch {
+	case function.Builtin != "":
+		result, msg := builtins[function.Builtin](args)
+		if msg != "" {
+			c.addError(exp.Pos(), "%s", msg)
+		}
+		return result
+	case function.Signature != nil:
+		signature := function.Signature
+		if len(args) != len(signature.Parameters) {
+			c.addError(exp.Pos(), "wrong number of arguments: want=%d, got=%d", len(signature.Parameters), len(args))
+			return signature.Return
+		}
+		for i, arg := range args {
+			want := signature.ParameterTypes[i]
+			if want.IsKnown() && arg.IsKnown() && arg.Name != want.Name {
+				c.addError(exp.Arguments[i].Pos(), "wrong type for parameter %s: want=%s, got=%s", signature.Parameters[i].Value, signature.annotations[i], arg)
+			}
+		}
+		return signature.Return
+	case function.IsKnown() && function.Name != object.FUNCTION_OBJ:
+		c.addError(exp.Pos(), "not a function: %s", function)
+		return Unknown
+	default:
+		return Unknown
+	}
+}
+
+func newSignature(fn *ast.FunctionLiteral) *Signature {
+	signature := &Signature{
+		Parameters:     fn.Parameters,
+		ParameterTypes: make([]Type, len(fn.Parameters)),
+		Return:         fromAnnotation(fn.ReturnType),
+		annotations:    make([]*ast.Type, len(fn.Parameters)),
+	}
+
+	for i := range fn.Parameters {
+		if i < len(fn.ParameterTypes) {
+			signature.ParameterTypes[i] = fromAnnotation(fn.ParameterTypes[i])
+			signature.annotations[i] = fn.ParameterTypes[i]
+		}
+	}
+
+	return signature
+}
+
+// checkFunctionBody checks the body of a function with its parameters in scope. If the
+// function doesn't have a return type, the signature's return type is inferred from the
+// return statements and the value that the body ends with
+func (c *Checker) checkFunctionBody(fn *ast.FunctionLiteral, signature *Signature, s *scope) {
+	if fn.Body == nil {
+		return
+	}
+
+	fnScope := newScope(s, fn.Body)
+	fnScope.function = true
+	for i, param := range fn.Parameters {
+		fnScope.set(param.Value, signature.ParameterTypes[i])
+	}
+
+	c.returns = append(c.returns, nil)
+	tail := c.checkStatements(fn.Body.Statements, fnScope)
+	returns := c.returns[len(c.returns)-1]
+	c.returns = c.returns[:len(c.returns)-1]
+
+	if tail != terminated {
+		pos := fn.Pos()
+		if len(fn.Body.Statements) > 0 {
+			pos = fn.Body.Statements[len(fn.Body.Statements)-1].Pos()
+		}
+		returns = append(returns, returnType{pos: pos, t: tail})
+	}
+
+	if fn.ReturnType != nil {
+		for _, r := range returns {
+			if r.t.IsKnown() && r.t.Name != signature.Return.Name {
+				c.addError(r.pos, "wrong return type: want=%s, got=%s", fn.ReturnType, r.t)
+			}
+		}
+		return
+	}
+
+	types := make([]Type, len(returns))
+	for i, r := range returns {
+		types[i] = r.t
+	}
+	signature.Return = union(types...)
+	if signature.Return == terminated {
+		signature.Return = Unknown
+	}
+}
+
+func checkArgCount(args []Type, want int) string {
+	if len(args) != want {
+		return fmt.Sprintf("wrong number of arguments. got=%d, want=%d", len(args), want)
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package checker
+
+import (
+	"testing"
+
+	"github.com/kai119/Brisk/src/lexer"
+	"github.com/kai119/Brisk/src/parser"
+)
+
+func TestCheckErrors(t *testing.T) {
+	tests := []struct {
+		input          string
+		expectedErrors []string
+	}{
+		{"1 + true;", []string{"1:1: type mismatch: INTEGER + BOOLEAN"}},
+		{"var x = 5; var y = x - \"a\";", []string{"1:20: unknown operator STRING - INTEGER"}},
+		{`"a" - "b";`, []string{"1:1: unknown operator STRING - STRING"}},
+		{"'a' * 'b';", []string{"1:1: unknown operator: CHAR * CHAR"}},
+		{"true + false;", []string{"1:1: unknown operator: BOOLEAN + BOOLEAN"}},
+		{"-true;", []string{"1:1: unknown operator: -BOOLEAN"}},
+		{"var x = 5; x();", []string{"1:12: not a function: INTEGER"}},
+		{"var add = func(a, b) { a + b }; add(1);", []string{"1:33: wrong number of arguments: want=2, got=1"}},
+		{"func add(a int, b int) { a + b } add(1, \"2\");", []string{"1:41: wrong type for parameter b: want=int, got=STRING"}},
+		{"len(1);", []string{"1:1: argument to 'len' not supported, got INTEGER"}},
+		{"len(\"a\", \"b\");", []string{"1:1: wrong number of arguments. got=2, want=1"}},
+		{"first(\"abc\");", []string{"1:1: argument to 'first' must be ARRAY, got STRING"}},
+		{"int(true);", []string{"1:1: argument to 'int' not supported, got BOOLEAN"}},
+		{"var x int = \"five\";", []string{"1:1: wrong type for variable x: want=int, got=STRING"}},
+		{"func name() str { return 5; }", []string{"1:19: wrong return type: want=str, got=INTEGER"}},
+		{"func name() str { }", []string{"1:1: wrong return type: want=str, got=NULL"}},
+		{"5[0];", []string{"1:1: index operator not supported: INTEGER"}},
+		{"{[1]: 2};", []string{"1:2: unusable as hash key: ARRAY"}},
+		{"for (x in 5) { x }", []string{"1:11: cannot iterate over INTEGER"}},
+		{"var x = \"a\"; var i = 0; while (i < 2) { x - 1; i += 1; }", []string{"1:41: unknown operator STRING - INTEGER"}},
+		{"var x = 1; x += \"a\";", []string{"1:12: unknown operator STRING + INTEGER"}},
+		{"var arr = [1]; arr[\"a\"] = 2;", []string{"1:20: array index must be INTEGER, got STRING"}},
+		{
+			"func double(x int) int { x * 2 } var s = double(2) + true; double(true);",
+			[]string{
+				"1:42: type mismatch: INTEGER + BOOLEAN",
+				"1:67: wrong type for parameter x: want=int, got=BOOLEAN",
+			},
+		},
+		{"var x, y = 5;", []string{"1:1: wrong number of values to unpack: want=2, got=1"}},
+		{"var [a, b] = {};", []string{"1:1: cannot destructure DICTIONARY as an array"}},
+		{
+			"var f = func() { var y = \"a\" * \"b\"; };",
+			[]string{"1:26: unknown operator STRING * STRING"},
+		},
+		{"var x = \"a\"; var f = func() { x - 1 };", []string{"1:31: unknown operator STRING - INTEGER"}},
+		{"var x = \"a\"; var f = func() { x - 1 }; var y = 1; y = 2;", []string{"1:31: unknown operator STRING - INTEGER"}},
+		{"var x = \"a\"; x = \"b\"; x - 1;", []string{"1:23: unknown operator STRING - INTEGER"}},
+	}
+
+	for _, tt := range tests {
+		errors := check(t, tt.input)
+
+		if len(errors) != len(tt.expectedErrors) {
+			t.Errorf("wrong number of errors for %q. expected=%q, got=%q", tt.input, tt.expectedErrors, errors)
+			continue
+		}
+
+		for i, msg := range tt.expectedErrors {
+			if errors[i] != msg {
+				t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, msg, errors[i])
+			}
+		}
+	}
+}
+
+func TestCheckValidPrograms(t *testing.T) {
+	tests := []string{
+		"1 + 2.5; \"a\" + 'b'; 'a' + 'b'; \"ab\" * 3; 3 * \"ab\"; 1 == true; [1] != [1];",
+		"var x = 5; if (x > 2) { x = \"big\"; } x + 1;",
+		"var f = func(a, b) { a + b }; f(1, 2) + f(\"a\", \"b\");",
+		"func isEven(n) { if (n == 0) { return true; } isOdd(n - 1) } func isOdd(n) { if (n == 0) { return false; } isEven(n - 1) } isEven(4) && isOdd(3);",
+		"func count() int { if (true) { return 1; } return 2; } count() + 1;",
+		"func half(n float) float { n / 2.0 } half(3.0) * 2.0;",
+		"var s = \"héllo\"; for (c in s) { int(c) + 1; } s[0] < 'z';",
+		"var d = {\"a\": 1}; d[\"a\"] + 1; d[\"b\"] = 2;",
+		"var total = 0; for (var i = 0; i < 10; i += 1) { total = total + i; } total * 2;",
+		"var x = unknown; x + 1; x();",
+		"len(args) + 1; str(true) + \"!\"; char(97) < 'b'; println(1, \"a\");",
+		"func name() str { if (true) { return \"a\"; } }",
+		"var x = \"a\"; var f = func() { x - 1 }; x = 1; println(f());",
+		"func count() { n + 1 } var n = \"a\"; for (var i = 0; i < 3; i += 1) { n = i; } count();",
+		"var f = func(a) { var g = func() { a * 2 }; a = 3; g() }; f(\"s\");",
+		"func pair() { return 1, \"a\"; } var x, y = pair(); x + 1; y + 1; var [a] = [1]; var {b} = {\"b\": 1};",
+		"var x = \"a\"; var i = 0; while (i < 2) { if (i == 1) { println(x - 1); } x = 5; i += 1; }",
+		"var x = \"a\"; for (var i = 0; i < 2; x = 5) { if (i == 1) { x - 1; } i += 1; }",
+		"var x = \"a\"; for (v in [1, 2]) { if (v == 2) { x - 1; } x = v; }",
+	}
+
+	for _, input := range tests {
+		errors := check(t, input)
+		if len(errors) != 0 {
+			t.Errorf("expected no errors for %q, got=%q", input, errors)
+		}
+	}
+}
+
+func check(t *testing.T, input string) []string {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has %d errors for %q: %q", len(p.Errors()), input, p.Errors())
+	}
+
+	var errors []string
+	for _, err := range Check(program) {
+		errors = append(errors, err.Error())
+	}
+	return errors
+}
@@ -0,0 +1,101 @@
+package checker
+
+import "github.com/kai119/Brisk/src/parser/ast"
+
+// scope holds the types of the variables that are visible to the code being checked. Like
+// the evaluator's environments, each function body and loop gets its own scope enclosed
+// by the scope it was declared in
+type scope struct {
+	types map[string]Type
+	outer *scope
+	// reassigned holds the names of the variables that are assigned to anywhere in the code
+	// of the scope, including inside the functions declared in it
+	reassigned map[string]bool
+	// function is true for the scope of a function body
+	function bool
+}
+
+// newScope creates a scope for the code given, enclosed by an outer scope
+func newScope(outer *scope, code ast.Node) *scope {
+	return &scope{types: make(map[string]Type), outer: outer, reassigned: assignedNames(code)}
+}
+
+func (s *scope) get(name string) (Type, bool) {
+	t, ok := s.types[name]
+	if !ok && s.outer != nil {
+		return s.outer.get(name)
+	}
+	return t, ok
+}
+
+// lookup gets the type of a variable used by the code being checked. A function can be called
+// after a variable that it captures from a scope around it has been reassigned, so a captured
+// variable that is assigned anywhere in the scope that declares it has an unknown type
+func (s *scope) lookup(name string) (Type, bool) {
+	captured := false
+	for current := s; current != nil; current = current.outer {
+		if t, ok := current.types[name]; ok {
+			if captured && current.reassigned[name] {
+				return Unknown, true
+			}
+			return t, true
+		}
+		if current.function {
+			captured = true
+		}
+	}
+	return Type{}, false
+}
+
+// set declares a variable in the scope. A variable that is declared more than once with
+// different types, such as in both branches of an if expression, could hold either type
+// afterwards, so its type becomes unknown
+func (s *scope) set(name string, t Type) {
+	if existing, ok := s.types[name]; ok && existing.Name != t.Name {
+		t = Unknown
+	}
+	s.types[name] = t
+}
+
+// assign updates the type of an existing variable in the scope it was declared in. The
+// variable could be assigned inside a branch or loop that doesn't run, so if the new type
+// is different the variable's type becomes unknown
+func (s *scope) assign(name string, t Type) {
+	for current := s; current != nil; current = current.outer {
+		if existing, ok := current.types[name]; ok {
+			if existing.Name != t.Name || existing.Signature != t.Signature {
+				current.types[name] = Unknown
+			}
+			return
+		}
+	}
+}
+
+// forgetAssigned makes the type of every variable that is assigned anywhere in the code of a
+// loop unknown before the loop is checked. A use near the start of the loop can run after an
+// assignment further on has changed the type in an earlier iteration
+func (s *scope) forgetAssigned(code ...ast.Node) {
+	for _, node := range code {
+		for name := range assignedNames(node) {
+			s.assign(name, Unknown)
+		}
+	}
+}
+
+// assignedNames returns the names of the variables that are assigned to anywhere in a node
+func assignedNames(node ast.Node) map[string]bool {
+	names := make(map[string]bool)
+	if node == nil {
+		return names
+	}
+
+	ast.Inspect(node, func(node ast.Node) bool {
+		if assign, ok := node.(*ast.AssignExpression); ok {
+			if target, ok := assign.Target.(*ast.Identifier); ok {
+				names[target.Value] = true
+			}
+		}
+		return true
+	})
+	return names
+}
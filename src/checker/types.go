@@ -0,0 +1,174 @@
+package checker
+
+import (
+	"github.com/kai119/Brisk/src/evaluator/object"
+	"github.com/kai119/Brisk/src/lexer/token"
+	"github.com/kai119/Brisk/src/parser/ast"
+)
+
+// Type is the type that the checker has inferred for an expression. Types are named after
+// the objects that the evaluator creates, so that the errors the checker reports read the
+// same as the errors that would happen at runtime. A type with an empty name is unknown,
+// and is never reported as a mismatch
+type Type struct {
+	Name object.Type
+
+	// Signature is the signature of a function, which is nil if the function is unknown
+	Signature *Signature
+
+	// Builtin is the name of a builtin function
+	Builtin string
+}
+
+// Signature describes the parameters and return type of a function
+type Signature struct {
+	Parameters     []*ast.Identifier
+	ParameterTypes []Type
+	Return         Type
+
+	// annotations holds the type annotation of each parameter, for use in error messages
+	annotations []*ast.Type
+}
+
+// The types of the values that BRISK expressions can result in
+var (
+	Unknown    = Type{}
+	Integer    = Type{Name: object.INTEGER_OBJ}
+	Float      = Type{Name: object.FLOAT_OBJ}
+	String     = Type{Name: object.STRING_OBJ}
+	Char       = Type{Name: object.CHAR_OBJ}
+	Boolean    = Type{Name: object.BOOLEAN_OBJ}
+	Null       = Type{Name: object.NULL_OBJ}
+	Array      = Type{Name: object.ARRAY_OBJ}
+	Dictionary = Type{Name: object.DICTIONARY_OBJ}
+	Tuple      = Type{Name: object.TUPLE_OBJ}
+)
+
+// terminated is the type of a block that always leaves through a return statement, so it
+// never results in a value of its own
+var terminated = Type{Name: "TERMINATED"}
+
+// IsKnown checks whether the checker was able to infer the type
+func (t Type) IsKnown() bool { return t.Name != "" && t != terminated }
+
+func (t Type) String() string {
+	if t.Name == "" {
+		return "UNKNOWN"
+	}
+	return string(t.Name)
+}
+
+// annotationTypes maps the names of the types used in annotations to the type they describe
+var annotationTypes = map[string]Type{
+	token.TYPE_INT:    Integer,
+	token.TYPE_STR:    String,
+	token.TYPE_BOOL:   Boolean,
+	token.TYPE_DOUBLE: Float,
+	token.TYPE_FLOAT:  Float,
+	token.TYPE_CHAR:   Char,
+}
+
+func fromAnnotation(annotation *ast.Type) Type {
+	if annotation == nil {
+		return Unknown
+	}
+	return annotationTypes[annotation.Name]
+}
+
+func isNumber(t Type) bool {
+	return t == Integer || t == Float
+}
+
+func isHashable(t Type) bool {
+	switch t {
+	case Integer, Float, String, Char, Boolean:
+		return true
+	default:
+		return false
+	}
+}
+
+// union is the type of a value that could come from any of the types. If they aren't
+// all the same type, the result is unknown
+func union(types ...Type) Type {
+	result := terminated
+	for _, t := range types {
+		if t == terminated {
+			continue
+		}
+		if result == terminated {
+			result = t
+		} else if result.Name != t.Name {
+			return Unknown
+		}
+	}
+	return result
+}
+
+// builtinSignature checks the arguments of a call to a builtin function, returning the
+// type of the result or a message explaining why the call would fail
+type builtinSignature func(args []Type) (Type, string)
+
+var builtins = map[string]builtinSignature{
+	"len": func(args []Type) (Type, string) {
+		if msg := checkArgCount(args, 1); msg != "" {
+			return Unknown, msg
+		}
+		if args[0].IsKnown() && args[0] != String && args[0] != Array {
+			return Unknown, "argument to 'len' not supported, got " + args[0].String()
+		}
+		return Integer, ""
+	},
+	"first": arrayBuiltin("first", Unknown),
+	"last":  arrayBuiltin("last", Unknown),
+	"tail":  arrayBuiltin("tail", Unknown),
+	"push": func(args []Type) (Type, string) {
+		if msg := checkArgCount(args, 2); msg != "" {
+			return Unknown, msg
+		}
+		if args[0].IsKnown() && args[0] != Array {
+			return Unknown, "argument to 'push' must be ARRAY, got " + args[0].String()
+		}
+		return Array, ""
+	},
+	"int":  conversionBuiltin("int", Integer, Integer, Char, Float, String),
+	"char": conversionBuiltin("char", Char, Char, Integer, String),
+	"str": func(args []Type) (Type, string) {
+		if msg := checkArgCount(args, 1); msg != "" {
+			return Unknown, msg
+		}
+		return String, ""
+	},
+	"println": func(args []Type) (Type, string) {
+		return Null, ""
+	},
+}
+
+func arrayBuiltin(name string, result Type) builtinSignature {
+	return func(args []Type) (Type, string) {
+		if msg := checkArgCount(args, 1); msg != "" {
+			return Unknown, msg
+		}
+		if args[0].IsKnown() && args[0] != Array {
+			return Unknown, "argument to '" + name + "' must be ARRAY, got " + args[0].String()
+		}
+		return result, ""
+	}
+}
+
+func conversionBuiltin(name string, result Type, accepted ...Type) builtinSignature {
+	return func(args []Type) (Type, string) {
+		if msg := checkArgCount(args, 1); msg != "" {
+			return Unknown, msg
+		}
+		if !args[0].IsKnown() {
+			return result, ""
+		}
+		for _, t := range accepted {
+			if args[0] == t {
+				return result, ""
+			}
+		}
+		return Unknown, "argument to '" + name + "' not supported, got " + args[0].String()
+	}
+}
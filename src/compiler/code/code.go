@@ -0,0 +1,322 @@
+// Package code defines the instructions that BRISK programs are compiled to. An instruction
+// is a one byte opcode followed by its operands, which are stored big-endian
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/kai119/Brisk/src/lexer/token"
+)
+
+// Instructions is a sequence of encoded instructions
+type Instructions []byte
+
+// Opcode is the first byte of an instruction, which decides what the instruction does
+type Opcode byte
+
+// The opcodes of the instruction set. The comment on each one shows what it takes off of the
+// stack and what it puts back
+const (
+	// OpConstant pushes the constant at the index in its operand
+	OpConstant Opcode = iota
+	// OpNull pushes null
+	OpNull
+	// OpNil pushes no value at all, which is the result of a statement such as a declaration
+	OpNil
+	// OpTrue pushes true
+	OpTrue
+	// OpFalse pushes false
+	OpFalse
+	// OpPop removes the value on top of the stack
+	OpPop
+	// OpSwap swaps the two values on top of the stack
+	OpSwap
+
+	// OpAdd and the other binary operators take the right operand off the top of the stack,
+	// then the left operand, and push the result
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpEqual
+	OpNotEqual
+	OpLessThan
+	OpGreaterThan
+	OpLessEqual
+	OpGreaterEqual
+
+	// OpMinus negates the value on top of the stack
+	OpMinus
+	// OpBang replaces the value on top of the stack with true if it is falsy, or false if not
+	OpBang
+
+	// OpJump jumps to the offset in its operand
+	OpJump
+	// OpJumpNotTruthy takes a value off the stack and jumps if it is falsy
+	OpJumpNotTruthy
+	// OpJumpTruthy takes a value off the stack and jumps if it is truthy
+	OpJumpTruthy
+
+	// OpGetGlobal pushes the value of a global variable
+	OpGetGlobal
+	// OpSetGlobal takes a value off the stack and declares a global variable with it
+	OpSetGlobal
+	// OpAssignGlobal assigns the value on top of the stack to a declared global variable,
+	// leaving it on the stack as the result of the assignment
+	OpAssignGlobal
+
+	// OpGetLocal, OpSetLocal and OpAssignLocal do the same for the local variables of a function
+	OpGetLocal
+	OpSetLocal
+	OpAssignLocal
+	// OpClearLocal removes a local variable when the loop that declared it ends
+	OpClearLocal
+
+	// OpGetCell, OpSetCell and OpAssignCell do the same for local variables that closures have
+	// captured, which are kept in cells that the closures share
+	OpGetCell
+	OpSetCell
+	OpAssignCell
+	// OpLoadCell pushes the cell of a captured local variable, to give it to a new closure
+	OpLoadCell
+	// OpBox moves the argument of a captured parameter into a cell
+	OpBox
+
+	// OpGetFree and OpAssignFree get and assign the variables that a closure has captured
+	OpGetFree
+	OpAssignFree
+	// OpLoadFree pushes the cell of a captured variable, to give it to a new closure
+	OpLoadFree
+
+	// OpArray takes the number of values in its operand off the stack and pushes an array
+	OpArray
+	// OpTuple takes the number of values in its operand off the stack and pushes a tuple
+	OpTuple
+	// OpDictionary takes the number of keys and values in its operand off the stack, with each
+	// key before its value, and pushes a dictionary
+	OpDictionary
+	// OpIndex takes an index and a value off the stack and pushes the value at the index
+	OpIndex
+	// OpSetIndex takes an index, a value and the value to assign off the stack and assigns it
+	// to the index, pushing the assigned value. A non-zero operand is the index of a compound
+	// assignment operator in Operators, which is applied to the current value first
+	OpSetIndex
+
+	// OpCall calls the function below the number of arguments in its operand
+	OpCall
+	// OpTailCall calls a function in the same way from the end of the current function, which
+	// the call takes the place of
+	OpTailCall
+	// OpReturnValue returns the value on top of the stack from the current function
+	OpReturnValue
+	// OpClosure pushes a closure of the function constant in its first operand, taking the
+	// number of captured cells in its second operand off the stack
+	OpClosure
+
+	// OpCheckType checks that the value on top of the stack has the type named by the string
+	// constant in its second operand, before it is declared as the variable named by the
+	// string constant in its first operand
+	OpCheckType
+	// OpDestructure takes a value off the stack and pushes the values of the variables in a
+	// destructuring declaration, in reverse so that the first variable's value is on top. Its
+	// operands are the kind of declaration and the index of an array constant holding the
+	// names of the variables
+	OpDestructure
+
+	// OpIter takes a value off the stack and pushes an iterator over its elements
+	OpIter
+	// OpIterNext pushes the next element of the iterator in the local variable in its first
+	// operand, or jumps to the offset in its second operand if there are no elements left
+	OpIterNext
+)
+
+// Operators are the operators of compound assignments, indexed by the operand of OpSetIndex
+var Operators = []string{"", "+", "-", "*", "/", "%"}
+
+// Definition is the name of an opcode and the width in bytes of each of its operands
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant:      {"OpConstant", []int{2}},
+	OpNull:          {"OpNull", []int{}},
+	OpNil:           {"OpNil", []int{}},
+	OpTrue:          {"OpTrue", []int{}},
+	OpFalse:         {"OpFalse", []int{}},
+	OpPop:           {"OpPop", []int{}},
+	OpSwap:          {"OpSwap", []int{}},
+	OpAdd:           {"OpAdd", []int{}},
+	OpSub:           {"OpSub", []int{}},
+	OpMul:           {"OpMul", []int{}},
+	OpDiv:           {"OpDiv", []int{}},
+	OpMod:           {"OpMod", []int{}},
+	OpEqual:         {"OpEqual", []int{}},
+	OpNotEqual:      {"OpNotEqual", []int{}},
+	OpLessThan:      {"OpLessThan", []int{}},
+	OpGreaterThan:   {"OpGreaterThan", []int{}},
+	OpLessEqual:     {"OpLessEqual", []int{}},
+	OpGreaterEqual:  {"OpGreaterEqual", []int{}},
+	OpMinus:         {"OpMinus", []int{}},
+	OpBang:          {"OpBang", []int{}},
+	OpJump:          {"OpJump", []int{2}},
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+	OpJumpTruthy:    {"OpJumpTruthy", []int{2}},
+	OpGetGlobal:     {"OpGetGlobal", []int{2}},
+	OpSetGlobal:     {"OpSetGlobal", []int{2}},
+	OpAssignGlobal:  {"OpAssignGlobal", []int{2}},
+	OpGetLocal:      {"OpGetLocal", []int{2}},
+	OpSetLocal:      {"OpSetLocal", []int{2}},
+	OpAssignLocal:   {"OpAssignLocal", []int{2}},
+	OpClearLocal:    {"OpClearLocal", []int{2}},
+	OpGetCell:       {"OpGetCell", []int{2}},
+	OpSetCell:       {"OpSetCell", []int{2}},
+	OpAssignCell:    {"OpAssignCell", []int{2}},
+	OpLoadCell:      {"OpLoadCell", []int{2}},
+	OpBox:           {"OpBox", []int{2}},
+	OpGetFree:       {"OpGetFree", []int{2}},
+	OpAssignFree:    {"OpAssignFree", []int{2}},
+	OpLoadFree:      {"OpLoadFree", []int{2}},
+	OpArray:         {"OpArray", []int{2}},
+	OpTuple:         {"OpTuple", []int{2}},
+	OpDictionary:    {"OpDictionary", []int{2}},
+	OpIndex:         {"OpIndex", []int{}},
+	OpSetIndex:      {"OpSetIndex", []int{1}},
+	OpCall:          {"OpCall", []int{1}},
+	OpTailCall:      {"OpTailCall", []int{1}},
+	OpReturnValue:   {"OpReturnValue", []int{}},
+	OpClosure:       {"OpClosure", []int{2, 2}},
+	OpCheckType:     {"OpCheckType", []int{2, 2}},
+	OpDestructure:   {"OpDestructure", []int{1, 2}},
+	OpIter:          {"OpIter", []int{}},
+	OpIterNext:      {"OpIterNext", []int{2, 2}},
+}
+
+// Lookup gets the definition of an opcode
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+
+	return def, nil
+}
+
+// Make encodes an instruction. It returns an empty instruction if the opcode doesn't exist
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	length := 1
+	for _, w := range def.OperandWidths {
+		length += w
+	}
+
+	instruction := make([]byte, length)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+
+	return instruction
+}
+
+// ReadOperands decodes the operands of an instruction, returning them along with the number
+// of bytes that they take up
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+		offset += width
+	}
+
+	return operands, offset
+}
+
+// ReadUint16 decodes a two byte operand
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+// ReadUint8 decodes a one byte operand
+func ReadUint8(ins Instructions) uint8 {
+	return ins[0]
+}
+
+// String returns a readable listing of the instructions, with the offset of each one, for
+// example 0003 OpConstant 1
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			i++
+			continue
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+		fmt.Fprintf(&out, "%04d %s\n", i, fmtInstruction(def, operands))
+
+		i += 1 + read
+	}
+
+	return out.String()
+}
+
+func fmtInstruction(def *Definition, operands []int) string {
+	if len(operands) != len(def.OperandWidths) {
+		return fmt.Sprintf("ERROR: operand len %d does not match defined %d\n", len(operands), len(def.OperandWidths))
+	}
+
+	out := def.Name
+	for _, operand := range operands {
+		out += fmt.Sprintf(" %d", operand)
+	}
+	return out
+}
+
+// Position records the position in the source code of the node that an instruction was
+// compiled from, so that errors at runtime can point to the code that caused them
+type Position struct {
+	Offset int
+	Pos    token.Position
+}
+
+// Positions are the positions of the instructions of a function that can fail, in order
+type Positions []Position
+
+// Lookup gets the source position of the instruction at the offset, which is the position of
+// the last instruction at or before the offset that has one
+func (p Positions) Lookup(offset int) token.Position {
+	i := sort.Search(len(p), func(i int) bool { return p[i].Offset > offset })
+	if i == 0 {
+		return token.Position{}
+	}
+	return p[i-1].Pos
+}
@@ -0,0 +1,753 @@
+// Package compiler lowers a BRISK program into bytecode for the virtual machine in the vm
+// package. The program and each function are compiled to a list of instructions, and the
+// values that the instructions use, such as literals and compiled functions, are kept in a
+// constant pool shared by the whole program
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/kai119/Brisk/src/compiler/code"
+	"github.com/kai119/Brisk/src/evaluator"
+	"github.com/kai119/Brisk/src/evaluator/object"
+	"github.com/kai119/Brisk/src/lexer/token"
+	"github.com/kai119/Brisk/src/parser/ast"
+)
+
+// ITERATOR is the name of the hidden local variable that holds the iterator of a for-in loop.
+// It can't clash with any variables in the program because it isn't a valid identifier
+const ITERATOR = "<iterator>"
+
+// placeholder is the operand of a jump whose destination hasn't been compiled yet
+const placeholder = 9999
+
+var infixOpcodes = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"%":  code.OpMod,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+	"<":  code.OpLessThan,
+	">":  code.OpGreaterThan,
+	"<=": code.OpLessEqual,
+	">=": code.OpGreaterEqual,
+}
+
+// Bytecode is a compiled program, ready to be run by the virtual machine
+type Bytecode struct {
+	// Main is the top level of the program, which is run like a function with no parameters
+	Main      *object.CompiledFunction
+	Constants []object.Object
+	// GlobalNames are the names of the global variables, indexed by their slot
+	GlobalNames []string
+}
+
+// Compiler compiles programs into bytecode
+type Compiler struct {
+	constants []object.Object
+	symbols   *SymbolTable
+	scopes    []*compilationScope
+
+	// strings and builtins hold the index in the constant pool of each string and builtin
+	// function, so that each one is only added once
+	strings  map[string]int
+	builtins map[string]int
+
+	err error
+}
+
+// compilationScope holds the instructions of the function that is being compiled
+type compilationScope struct {
+	instructions code.Instructions
+	positions    code.Positions
+	// loops are the loops that are being compiled inside of the function, innermost last
+	loops []*loop
+}
+
+// loop holds the jumps of the break and continue statements of a loop, which are pointed at
+// the end of the loop and the start of its next iteration once they are known
+type loop struct {
+	breaks    []int
+	continues []int
+}
+
+// New creates a new compiler
+func New() *Compiler {
+	return NewWithState(NewSymbolTable(), []object.Object{})
+}
+
+// NewWithState creates a compiler that carries on from the global variables and constants
+// of an earlier program, so that the REPL can compile one line at a time
+func NewWithState(symbols *SymbolTable, constants []object.Object) *Compiler {
+	return &Compiler{
+		constants: constants,
+		symbols:   symbols,
+		scopes:    []*compilationScope{{}},
+		strings:   make(map[string]int),
+		builtins:  make(map[string]int),
+	}
+}
+
+// Compile compiles a program. The result of the program is the value of its last statement,
+// just as it is for the evaluator. The program is resolved first in the same way as it is by
+// the evaluator, so undeclared variables and duplicate declarations are reported as errors
+// without compiling any of the program
+func (c *Compiler) Compile(program *ast.Program) error {
+	if err := evaluator.Resolve(program, c.symbols.GlobalNames()); err != nil {
+		return err
+	}
+
+	c.declare(program.Statements, nil)
+	c.statements(program.Statements, true)
+	c.emit(code.OpReturnValue)
+
+	return c.err
+}
+
+// Bytecode returns the compiled program
+func (c *Compiler) Bytecode() *Bytecode {
+	scope := c.scope()
+	global := c.symbols.Global()
+
+	return &Bytecode{
+		Main: &object.CompiledFunction{
+			Instructions: scope.instructions,
+			Positions:    scope.positions,
+			LocalNames:   global.localNames,
+		},
+		Constants:   c.constants,
+		GlobalNames: global.GlobalNames(),
+	}
+}
+
+// statements compiles a list of statements. If the value of the statements is needed, the
+// value of the last statement is left on the stack
+func (c *Compiler) statements(statements []ast.Statement, value bool) {
+	c.hoistFunctions(statements)
+
+	if len(statements) == 0 {
+		if value {
+			c.emit(code.OpNil)
+		}
+		return
+	}
+
+	for i, s := range statements {
+		c.statement(s, value && i == len(statements)-1)
+	}
+}
+
+// hoistFunctions binds every function declared in the statements before any of them are run,
+// so that functions can be called before they are declared and can call each other
+func (c *Compiler) hoistFunctions(statements []ast.Statement) {
+	for _, s := range statements {
+		if fs, ok := s.(*ast.FunctionStatement); ok {
+			c.function(fs.Function)
+			c.setVariable(fs.Name.Value)
+		}
+	}
+}
+
+func (c *Compiler) statement(s ast.Statement, value bool) {
+	switch s := s.(type) {
+	case *ast.ExpressionStatement:
+		c.expression(s.Expression)
+		if !value {
+			c.emit(code.OpPop)
+		}
+		return
+	case *ast.VarStatement:
+		c.varStatement(s)
+	case *ast.FunctionStatement:
+		// named functions are bound by hoistFunctions before the statements around them are run
+	case *ast.ReturnStatement:
+		c.expression(s.ReturnValue)
+		c.emit(code.OpReturnValue)
+		return
+	case *ast.BreakStatement:
+		if l := c.currentLoop(s.Pos(), "break"); l != nil {
+			l.breaks = append(l.breaks, c.emit(code.OpJump, placeholder))
+		}
+		return
+	case *ast.ContinueStatement:
+		if l := c.currentLoop(s.Pos(), "continue"); l != nil {
+			l.continues = append(l.continues, c.emit(code.OpJump, placeholder))
+		}
+		return
+	case *ast.WhileStatement:
+		c.whileStatement(s)
+		if value {
+			c.emit(code.OpNull)
+		}
+		return
+	case *ast.ForStatement:
+		c.forStatement(s)
+		if value {
+			c.emit(code.OpNull)
+		}
+		return
+	case *ast.ForInStatement:
+		c.forInStatement(s)
+		if value {
+			c.emit(code.OpNull)
+		}
+		return
+	default:
+		c.errorf(s.Pos(), "cannot compile %T", s)
+	}
+
+	// declarations have no value
+	if value {
+		c.emit(code.OpNil)
+	}
+}
+
+func (c *Compiler) varStatement(s *ast.VarStatement) {
+	c.expression(s.Value)
+
+	if s.Destructure != ast.NoDestructure {
+		names := make([]object.Object, len(s.Names))
+		for i, name := range s.Names {
+			names[i] = &object.String{Value: name.Value}
+		}
+		c.emitAt(s.Pos(), code.OpDestructure, int(s.Destructure), c.addConstant(&object.Array{Elements: names}))
+
+		for _, name := range s.Names {
+			c.setVariable(name.Value)
+		}
+		return
+	}
+
+	if s.Type != nil {
+		c.emitAt(s.Pos(), code.OpCheckType, c.stringConstant(s.Name.Value), c.stringConstant(s.Type.Name))
+	}
+	c.setVariable(s.Name.Value)
+}
+
+// whileStatement compiles a while loop. The body shares the scope around the loop
+func (c *Compiler) whileStatement(ws *ast.WhileStatement) {
+	start := len(c.scope().instructions)
+
+	c.expression(ws.Condition)
+	exit := c.emit(code.OpJumpNotTruthy, placeholder)
+
+	l := c.loopBody(ws.Body)
+	c.emit(code.OpJump, start)
+
+	c.patchJump(exit)
+	c.patchLoop(l, start)
+}
+
+// forStatement compiles a C-style for loop. The loop gets its own scope so that variables
+// declared in the init statement only exist inside of the loop
+func (c *Compiler) forStatement(fs *ast.ForStatement) {
+	captured := capturedNames(fs)
+	c.enterBlock()
+
+	if fs.Init != nil {
+		c.declare([]ast.Statement{fs.Init}, captured)
+	}
+	c.declare(fs.Body.Statements, captured)
+
+	if fs.Init != nil {
+		c.statement(fs.Init, false)
+	}
+
+	start := len(c.scope().instructions)
+	exit := -1
+	if fs.Condition != nil {
+		c.expression(fs.Condition)
+		exit = c.emit(code.OpJumpNotTruthy, placeholder)
+	}
+
+	l := c.loopBody(fs.Body)
+
+	post := len(c.scope().instructions)
+	if fs.Post != nil {
+		c.statement(fs.Post, false)
+	}
+	c.emit(code.OpJump, start)
+
+	if exit >= 0 {
+		c.patchJump(exit)
+	}
+	c.patchLoop(l, post)
+
+	c.leaveBlock()
+}
+
+// forInStatement compiles a loop over the elements of an array, the characters of a string or
+// the keys of a dictionary. The elements are worked out before the loop starts, and are kept
+// in a hidden variable of the loop's own scope
+func (c *Compiler) forInStatement(fi *ast.ForInStatement) {
+	c.expression(fi.Iterable)
+	c.emitAt(fi.Pos(), code.OpIter)
+
+	captured := capturedNames(fi.Body)
+	c.enterBlock()
+
+	iterator := c.symbols.Define(ITERATOR, false)
+	c.symbols.Define(fi.Variable.Value, captured[fi.Variable.Value])
+	c.declare(fi.Body.Statements, captured)
+
+	c.emit(code.OpSetLocal, iterator.Index)
+
+	start := c.emit(code.OpIterNext, iterator.Index, placeholder)
+	c.setVariable(fi.Variable.Value)
+
+	l := c.loopBody(fi.Body)
+	c.emit(code.OpJump, start)
+
+	c.replaceInstruction(start, code.Make(code.OpIterNext, iterator.Index, c.jumpTarget()))
+	c.patchLoop(l, start)
+
+	c.leaveBlock()
+}
+
+// loopBody compiles the body of a loop, collecting the jumps of its break and continue statements
+func (c *Compiler) loopBody(body *ast.BlockStatement) *loop {
+	scope := c.scope()
+	l := &loop{}
+
+	scope.loops = append(scope.loops, l)
+	c.statements(body.Statements, false)
+	scope.loops = scope.loops[:len(scope.loops)-1]
+
+	return l
+}
+
+// patchLoop points the break statements of a loop at the current instruction, which is the
+// end of the loop, and the continue statements at the start of the next iteration
+func (c *Compiler) patchLoop(l *loop, next int) {
+	for _, pos := range l.breaks {
+		c.patchJump(pos)
+	}
+	for _, pos := range l.continues {
+		c.replaceInstruction(pos, code.Make(code.OpJump, next))
+	}
+}
+
+func (c *Compiler) currentLoop(pos token.Position, statement string) *loop {
+	loops := c.scope().loops
+	if len(loops) == 0 {
+		c.errorf(pos, "%s statement outside of loop", statement)
+		return nil
+	}
+	return loops[len(loops)-1]
+}
+
+func (c *Compiler) expression(exp ast.Expression) {
+	if exp == nil {
+		c.emit(code.OpNil)
+		return
+	}
+
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: exp.Value}))
+	case *ast.FloatLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Float{Value: exp.Value}))
+	case *ast.StringLiteral:
+		c.emit(code.OpConstant, c.stringConstant(exp.Value))
+	case *ast.CharLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Char{Value: exp.Value}))
+	case *ast.Boolean:
+		if exp.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+	case *ast.Identifier:
+		c.getVariable(exp.Value, exp.Pos())
+	case *ast.PrefixExpression:
+		c.expression(exp.Right)
+		switch exp.Operator {
+		case "-":
+			c.emitAt(exp.Pos(), code.OpMinus)
+		case "!":
+			c.emitAt(exp.Pos(), code.OpBang)
+		default:
+			c.errorf(exp.Pos(), "unknown operator: %s", exp.Operator)
+		}
+	case *ast.InfixExpression:
+		if exp.Token.Type == token.LOGICAL_AND || exp.Token.Type == token.LOGICAL_OR {
+			c.logicalExpression(exp)
+			return
+		}
+		op, ok := infixOpcodes[exp.Operator]
+		if !ok {
+			c.errorf(exp.Pos(), "unknown operator: %s", exp.Operator)
+			return
+		}
+		c.expression(exp.Left)
+		c.expression(exp.Right)
+		c.emitAt(exp.Pos(), op)
+	case *ast.IfExpression:
+		c.ifExpression(exp)
+	case *ast.FunctionLiteral:
+		c.function(exp)
+	case *ast.CallExpression:
+		if len(exp.Arguments) > math.MaxUint8 {
+			c.errorf(exp.Pos(), "too many arguments in call: %d", len(exp.Arguments))
+			return
+		}
+		c.expression(exp.Function)
+		c.expressions(exp.Arguments)
+		if exp.Tail {
+			c.emitAt(exp.Pos(), code.OpTailCall, len(exp.Arguments))
+		} else {
+			c.emitAt(exp.Pos(), code.OpCall, len(exp.Arguments))
+		}
+	case *ast.ArrayLiteral:
+		c.expressions(exp.Elements)
+		c.emit(code.OpArray, c.count(exp.Pos(), len(exp.Elements)))
+	case *ast.TupleLiteral:
+		c.expressions(exp.Elements)
+		c.emit(code.OpTuple, c.count(exp.Pos(), len(exp.Elements)))
+	case *ast.IndexExpression:
+		c.expression(exp.Left)
+		c.expression(exp.Index)
+		c.emitAt(exp.Pos(), code.OpIndex)
+	case *ast.DictionaryLiteral:
+		for _, key := range ast.SortedKeys(exp) {
+			c.expression(key)
+			c.expression(exp.Pairs[key])
+		}
+		c.emitAt(exp.Pos(), code.OpDictionary, c.count(exp.Pos(), 2*len(exp.Pairs)))
+	case *ast.AssignExpression:
+		c.assignExpression(exp)
+	default:
+		c.errorf(exp.Pos(), "cannot compile %T", exp)
+	}
+}
+
+func (c *Compiler) expressions(exps []ast.Expression) {
+	for _, exp := range exps {
+		c.expression(exp)
+	}
+}
+
+// logicalExpression compiles 'and' and 'or' expressions, which can also be written as '&&'
+// and '||'. The right side is jumped over if the left side already decides the result
+func (c *Compiler) logicalExpression(exp *ast.InfixExpression) {
+	isAnd := exp.Token.Type == token.LOGICAL_AND
+
+	c.expression(exp.Left)
+	var shortCircuit int
+	if isAnd {
+		shortCircuit = c.emit(code.OpJumpNotTruthy, placeholder)
+	} else {
+		shortCircuit = c.emit(code.OpJumpTruthy, placeholder)
+	}
+
+	// negating the right side twice turns it into a boolean
+	c.expression(exp.Right)
+	c.emit(code.OpBang)
+	c.emit(code.OpBang)
+	end := c.emit(code.OpJump, placeholder)
+
+	c.patchJump(shortCircuit)
+	if isAnd {
+		c.emit(code.OpFalse)
+	} else {
+		c.emit(code.OpTrue)
+	}
+	c.patchJump(end)
+}
+
+func (c *Compiler) ifExpression(ie *ast.IfExpression) {
+	c.expression(ie.Condition)
+	jumpNotTruthy := c.emit(code.OpJumpNotTruthy, placeholder)
+
+	c.statements(ie.Consequence.Statements, true)
+	jump := c.emit(code.OpJump, placeholder)
+
+	c.patchJump(jumpNotTruthy)
+	if ie.Alternative != nil {
+		c.statements(ie.Alternative.Statements, true)
+	} else {
+		c.emit(code.OpNull)
+	}
+	c.patchJump(jump)
+}
+
+// assignExpression compiles an assignment to an existing variable or to an index of an array
+// or dictionary. The value is compiled first, and is left on the stack as the result
+func (c *Compiler) assignExpression(exp *ast.AssignExpression) {
+	c.expression(exp.Value)
+
+	switch target := exp.Target.(type) {
+	case *ast.Identifier:
+		if exp.Operator != "=" {
+			op, ok := infixOpcodes[strings.TrimSuffix(exp.Operator, "=")]
+			if !ok {
+				c.errorf(exp.Pos(), "unknown operator: %s", exp.Operator)
+				return
+			}
+			c.getVariable(target.Value, exp.Pos())
+			c.emit(code.OpSwap)
+			c.emitAt(exp.Pos(), op)
+		}
+		c.assignVariable(target.Value, exp.Pos())
+	case *ast.IndexExpression:
+		operator := 0
+		if exp.Operator != "=" {
+			operator = operatorIndex(strings.TrimSuffix(exp.Operator, "="))
+			if operator == 0 {
+				c.errorf(exp.Pos(), "unknown operator: %s", exp.Operator)
+				return
+			}
+		}
+		c.expression(target.Left)
+		c.expression(target.Index)
+		c.emitAt(exp.Pos(), code.OpSetIndex, operator)
+	default:
+		c.errorf(exp.Pos(), "cannot assign to %s", exp.Target.String())
+	}
+}
+
+func operatorIndex(operator string) int {
+	for i, op := range code.Operators {
+		if i > 0 && op == operator {
+			return i
+		}
+	}
+	return 0
+}
+
+// function compiles a function literal into a constant and pushes a closure of it, capturing
+// the cells of the variables that it uses from the functions around it
+func (c *Compiler) function(fn *ast.FunctionLiteral) {
+	captured := capturedNames(fn.Body)
+	c.enterScope()
+
+	for _, param := range fn.Parameters {
+		c.symbols.Define(param.Value, captured[param.Value])
+	}
+	c.declare(fn.Body.Statements, captured)
+
+	for _, param := range fn.Parameters {
+		if symbol, _ := c.symbols.Resolve(param.Value); symbol.Captured {
+			c.emit(code.OpBox, symbol.Index)
+		}
+	}
+
+	c.statements(fn.Body.Statements, true)
+	c.emit(code.OpReturnValue)
+
+	free := c.symbols.FreeSymbols
+	freeNames := make([]string, len(free))
+	for i, symbol := range free {
+		freeNames[i] = symbol.Name
+	}
+	localNames := c.symbols.localNames
+	scope := c.leaveScope()
+
+	for _, symbol := range free {
+		if symbol.Scope == FreeScope {
+			c.emit(code.OpLoadFree, symbol.Index)
+		} else {
+			c.emit(code.OpLoadCell, symbol.Index)
+		}
+	}
+
+	compiled := &object.CompiledFunction{
+		Instructions:  scope.instructions,
+		Positions:     scope.positions,
+		NumParameters: len(fn.Parameters),
+		LocalNames:    localNames,
+		FreeNames:     freeNames,
+		Literal:       fn,
+	}
+	c.emit(code.OpClosure, c.addConstant(compiled), len(free))
+}
+
+// declare declares the variables of the statements of a scope before the scope is compiled,
+// so that every use of a variable in the scope refers to the same slot no matter where it is
+// declared. Uses before the declaration is reached refer to the variable around the scope
+// instead, as they do in the evaluator. Variables that closures might use are kept in cells
+func (c *Compiler) declare(statements []ast.Statement, captured map[string]bool) {
+	for _, name := range declaredNames(statements) {
+		c.symbols.Declare(name, captured[name])
+	}
+}
+
+// getVariable pushes the value of a variable. A name that isn't declared anywhere that the
+// compiler can see is either a builtin function or a global variable that will be declared later,
+// by a later line of the REPL for example
+func (c *Compiler) getVariable(name string, pos token.Position) {
+	symbol, ok := c.symbols.Resolve(name)
+	if !ok {
+		if builtin, ok := evaluator.Builtins[name]; ok {
+			c.emit(code.OpConstant, c.builtinConstant(name, builtin))
+			return
+		}
+		symbol = c.symbols.Global().Define(name, false)
+	}
+
+	switch {
+	case symbol.Scope == GlobalScope:
+		c.emitAt(pos, code.OpGetGlobal, symbol.Index)
+	case symbol.Scope == FreeScope:
+		c.emitAt(pos, code.OpGetFree, symbol.Index)
+	case symbol.Captured:
+		c.emitAt(pos, code.OpGetCell, symbol.Index)
+	default:
+		c.emitAt(pos, code.OpGetLocal, symbol.Index)
+	}
+}
+
+// setVariable takes a value off the stack and declares a variable of the current scope with it
+func (c *Compiler) setVariable(name string) {
+	symbol := c.symbols.Define(name, false)
+
+	switch {
+	case symbol.Scope == GlobalScope:
+		c.emit(code.OpSetGlobal, symbol.Index)
+	case symbol.Captured:
+		c.emit(code.OpSetCell, symbol.Index)
+	default:
+		c.emit(code.OpSetLocal, symbol.Index)
+	}
+}
+
+// assignVariable assigns the value on top of the stack to a variable that has already been declared
+func (c *Compiler) assignVariable(name string, pos token.Position) {
+	symbol, ok := c.symbols.Resolve(name)
+	if !ok {
+		symbol = c.symbols.Global().Define(name, false)
+	}
+
+	switch {
+	case symbol.Scope == GlobalScope:
+		c.emitAt(pos, code.OpAssignGlobal, symbol.Index)
+	case symbol.Scope == FreeScope:
+		c.emitAt(pos, code.OpAssignFree, symbol.Index)
+	case symbol.Captured:
+		c.emitAt(pos, code.OpAssignCell, symbol.Index)
+	default:
+		c.emitAt(pos, code.OpAssignLocal, symbol.Index)
+	}
+}
+
+func (c *Compiler) addConstant(obj object.Object) int {
+	if len(c.constants) > math.MaxUint16 {
+		c.errorf(token.Position{}, "too many constants")
+		return 0
+	}
+
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1
+}
+
+func (c *Compiler) stringConstant(s string) int {
+	if idx, ok := c.strings[s]; ok {
+		return idx
+	}
+
+	idx := c.addConstant(&object.String{Value: s})
+	c.strings[s] = idx
+	return idx
+}
+
+func (c *Compiler) builtinConstant(name string, builtin *object.Builtin) int {
+	if idx, ok := c.builtins[name]; ok {
+		return idx
+	}
+
+	idx := c.addConstant(builtin)
+	c.builtins[name] = idx
+	return idx
+}
+
+// count checks that the number of values in a literal fits in an operand
+func (c *Compiler) count(pos token.Position, n int) int {
+	if n > math.MaxUint16 {
+		c.errorf(pos, "too many values in literal: %d", n)
+		return 0
+	}
+	return n
+}
+
+func (c *Compiler) scope() *compilationScope {
+	return c.scopes[len(c.scopes)-1]
+}
+
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	scope := c.scope()
+	pos := len(scope.instructions)
+	scope.instructions = append(scope.instructions, code.Make(op, operands...)...)
+	return pos
+}
+
+// emitAt emits an instruction that can fail, recording the position in the source code that
+// errors from the instruction are given
+func (c *Compiler) emitAt(pos token.Position, op code.Opcode, operands ...int) int {
+	offset := c.emit(op, operands...)
+
+	scope := c.scope()
+	scope.positions = append(scope.positions, code.Position{Offset: offset, Pos: pos})
+	return offset
+}
+
+func (c *Compiler) replaceInstruction(pos int, instruction []byte) {
+	copy(c.scope().instructions[pos:], instruction)
+}
+
+// jumpTarget returns the offset of the next instruction, checking that a jump can reach it
+func (c *Compiler) jumpTarget() int {
+	target := len(c.scope().instructions)
+	if target > math.MaxUint16 {
+		c.errorf(token.Position{}, "function is too large to compile")
+		return 0
+	}
+	return target
+}
+
+// patchJump points the jump at the offset to the next instruction
+func (c *Compiler) patchJump(pos int) {
+	op := code.Opcode(c.scope().instructions[pos])
+	c.replaceInstruction(pos, code.Make(op, c.jumpTarget()))
+}
+
+func (c *Compiler) enterScope() {
+	c.scopes = append(c.scopes, &compilationScope{})
+	c.symbols = NewEnclosedSymbolTable(c.symbols)
+}
+
+func (c *Compiler) leaveScope() *compilationScope {
+	scope := c.scope()
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.symbols = c.symbols.Outer
+	return scope
+}
+
+func (c *Compiler) enterBlock() {
+	c.symbols = NewBlockSymbolTable(c.symbols)
+}
+
+// leaveBlock clears the variables of a loop once it ends, so that running the loop again
+// starts with new variables, just as it gets a new environment in the evaluator
+func (c *Compiler) leaveBlock() {
+	for _, symbol := range c.symbols.Locals() {
+		c.emit(code.OpClearLocal, symbol.Index)
+	}
+	c.symbols = c.symbols.Outer
+}
+
+func (c *Compiler) errorf(pos token.Position, format string, a ...interface{}) {
+	if c.err != nil {
+		return
+	}
+
+	msg := fmt.Sprintf(format, a...)
+	if pos.IsValid() {
+		msg = pos.String() + ": " + msg
+	}
+	c.err = errors.New(msg)
+}
@@ -0,0 +1,388 @@
+package compiler
+
+import (
+	"testing"
+
+	"github.com/kai119/Brisk/src/compiler/code"
+	"github.com/kai119/Brisk/src/evaluator/object"
+	"github.com/kai119/Brisk/src/lexer"
+	"github.com/kai119/Brisk/src/parser"
+	"github.com/kai119/Brisk/src/parser/ast"
+)
+
+type compilerTestCase struct {
+	input                string
+	expectedConstants    []interface{}
+	expectedInstructions []code.Instructions
+}
+
+func TestExpressions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			"1 + 2",
+			[]interface{}{1, 2},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"1; -2",
+			[]interface{}{1, 2},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpMinus),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"true && false",
+			[]interface{}{},
+			[]code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 10),
+				code.Make(code.OpFalse),
+				code.Make(code.OpBang),
+				code.Make(code.OpBang),
+				code.Make(code.OpJump, 11),
+				code.Make(code.OpFalse),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"if (true) { 10 }",
+			[]interface{}{10},
+			[]code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 10),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpJump, 11),
+				code.Make(code.OpNull),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			`{"b": 2, "a": 1}["a"]`,
+			[]interface{}{"b", 2, "a", 1},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpConstant, 3),
+				code.Make(code.OpDictionary, 4),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpIndex),
+				code.Make(code.OpReturnValue),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestStatements(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			"var x = 1; x += 2;",
+			[]interface{}{1, 2},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpSwap),
+				code.Make(code.OpAdd),
+				code.Make(code.OpAssignGlobal, 0),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"var x int = 1;",
+			[]interface{}{1, "x", "int"},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpCheckType, 1, 2),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpNil),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"while (true) { break; }",
+			[]interface{}{},
+			[]code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 10),
+				code.Make(code.OpJump, 10),
+				code.Make(code.OpJump, 0),
+				code.Make(code.OpNull),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"for (v in [1]) { v }",
+			[]interface{}{1},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpIter),
+				code.Make(code.OpSetLocal, 0),
+				code.Make(code.OpIterNext, 0, 25),
+				code.Make(code.OpSetLocal, 1),
+				code.Make(code.OpGetLocal, 1),
+				code.Make(code.OpPop),
+				code.Make(code.OpJump, 10),
+				code.Make(code.OpClearLocal, 0),
+				code.Make(code.OpClearLocal, 1),
+				code.Make(code.OpNull),
+				code.Make(code.OpReturnValue),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestFunctions(t *testing.T) {
+	tests := []compilerTestCase{
+		{
+			"func(a, b) { a + b }(1, 2)",
+			[]interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetLocal, 0),
+					code.Make(code.OpGetLocal, 1),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+				1,
+				2,
+			},
+			[]code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpCall, 2),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"func f() { f() }",
+			[]interface{}{
+				[]code.Instructions{
+					code.Make(code.OpGetGlobal, 0),
+					code.Make(code.OpTailCall, 0),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			[]code.Instructions{
+				code.Make(code.OpClosure, 0, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpNil),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"func f() { 1 + f() }",
+			[]interface{}{
+				1,
+				[]code.Instructions{
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpGetGlobal, 0),
+					code.Make(code.OpCall, 0),
+					code.Make(code.OpAdd),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			[]code.Instructions{
+				code.Make(code.OpClosure, 1, 0),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpNil),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"func(a) { func() { a = 2 } }",
+			[]interface{}{
+				2,
+				[]code.Instructions{
+					code.Make(code.OpConstant, 0),
+					code.Make(code.OpAssignFree, 0),
+					code.Make(code.OpReturnValue),
+				},
+				[]code.Instructions{
+					code.Make(code.OpBox, 0),
+					code.Make(code.OpLoadCell, 0),
+					code.Make(code.OpClosure, 1, 1),
+					code.Make(code.OpReturnValue),
+				},
+			},
+			[]code.Instructions{
+				code.Make(code.OpClosure, 2, 0),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"len([])",
+			[]interface{}{},
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 0),
+				code.Make(code.OpCall, 1),
+				code.Make(code.OpReturnValue),
+			},
+		},
+	}
+
+	runCompilerTests(t, tests)
+}
+
+func TestCompilerPositions(t *testing.T) {
+	program := parse(t, "var x = 1; var y = 2;\nx + y;")
+
+	c := New()
+	if err := c.Compile(program); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	main := c.Bytecode().Main
+	tests := []struct {
+		op       code.Opcode
+		expected string
+	}{
+		{code.OpGetGlobal, "2:1"},
+		{code.OpAdd, "2:1"},
+	}
+
+	for _, tt := range tests {
+		found := false
+		for _, pos := range main.Positions {
+			if code.Opcode(main.Instructions[pos.Offset]) == tt.op && pos.Pos.String() == tt.expected {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("no position %s for %d in %+v", tt.expected, tt.op, main.Positions)
+		}
+	}
+}
+
+func TestCompileResolveErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var a = 1; var a = 2; a", "1:16: duplicate declaration: a"},
+		{"func g() { var y = 1; var y = 2; y } g()", "1:27: duplicate declaration: y"},
+		{"func f() { z }", "1:12: identifier not found: z"},
+		{"len([]) + w", "1:11: identifier not found: w"},
+	}
+
+	for _, tt := range tests {
+		err := New().Compile(parse(t, tt.input))
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%v", tt.input, tt.expected, err)
+		}
+	}
+}
+
+func TestGlobalNamesAcrossPrograms(t *testing.T) {
+	symbols := NewSymbolTable()
+	constants := []object.Object{}
+
+	for _, input := range []string{"var f = func() { 1 };", "var later = f(); later;"} {
+		c := NewWithState(symbols, constants)
+		if err := c.Compile(parse(t, input)); err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+		constants = c.Bytecode().Constants
+	}
+
+	names := symbols.GlobalNames()
+	if len(names) != 2 || names[0] != "f" || names[1] != "later" {
+		t.Errorf("wrong global names. got=%q", names)
+	}
+}
+
+func runCompilerTests(t *testing.T, tests []compilerTestCase) {
+	t.Helper()
+
+	for _, tt := range tests {
+		c := New()
+		if err := c.Compile(parse(t, tt.input)); err != nil {
+			t.Fatalf("compiler error for %q: %s", tt.input, err)
+		}
+
+		bytecode := c.Bytecode()
+		testInstructions(t, tt.input, tt.expectedInstructions, bytecode.Main.Instructions)
+		testConstants(t, tt.input, tt.expectedConstants, bytecode.Constants)
+	}
+}
+
+func testInstructions(t *testing.T, input string, expected []code.Instructions, actual code.Instructions) {
+	t.Helper()
+
+	concatted := code.Instructions{}
+	for _, ins := range expected {
+		concatted = append(concatted, ins...)
+	}
+
+	if concatted.String() != actual.String() {
+		t.Errorf("wrong instructions for %q.\nexpected=\n%s\ngot=\n%s", input, concatted, actual)
+	}
+}
+
+func testConstants(t *testing.T, input string, expected []interface{}, actual []object.Object) {
+	t.Helper()
+
+	constants := 0
+	for _, constant := range actual {
+		// builtin functions are kept in the constant pool but aren't listed in the tests
+		if _, ok := constant.(*object.Builtin); !ok {
+			actual[constants] = constant
+			constants++
+		}
+	}
+	actual = actual[:constants]
+
+	if len(expected) != len(actual) {
+		t.Errorf("wrong number of constants for %q. expected=%d, got=%d", input, len(expected), len(actual))
+		return
+	}
+
+	for i, constant := range expected {
+		switch constant := constant.(type) {
+		case int:
+			integer, ok := actual[i].(*object.Integer)
+			if !ok || integer.Value != int64(constant) {
+				t.Errorf("constant %d for %q is not %d. got=%s", i, input, constant, actual[i].Inspect())
+			}
+		case string:
+			str, ok := actual[i].(*object.String)
+			if !ok || str.Value != constant {
+				t.Errorf("constant %d for %q is not %q. got=%s", i, input, constant, actual[i].Inspect())
+			}
+		case []code.Instructions:
+			fn, ok := actual[i].(*object.CompiledFunction)
+			if !ok {
+				t.Errorf("constant %d for %q is not a function. got=%T", i, input, actual[i])
+				continue
+			}
+			testInstructions(t, input, constant, fn.Instructions)
+		}
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors for %q: %q", input, p.Errors())
+	}
+	return program
+}
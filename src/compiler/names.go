@@ -0,0 +1,64 @@
+package compiler
+
+import "github.com/kai119/Brisk/src/parser/ast"
+
+// declaredNames finds the names of the variables and functions declared by the statements of
+// a scope, in the order they are declared. If and while blocks share the scope around them,
+// so declarations inside of them are included, while functions and for loops have scopes of
+// their own and are skipped
+func declaredNames(statements []ast.Statement) []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	for _, s := range statements {
+		ast.Inspect(s, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.VarStatement:
+				if node.Destructure != ast.NoDestructure {
+					for _, name := range node.Names {
+						add(name.Value)
+					}
+				} else {
+					add(node.Name.Value)
+				}
+			case *ast.FunctionStatement:
+				add(node.Name.Value)
+				return false
+			case *ast.FunctionLiteral, *ast.ForStatement, *ast.ForInStatement:
+				return false
+			}
+			return true
+		})
+	}
+
+	return names
+}
+
+// capturedNames finds the names used inside of the functions nested in a node, which are the
+// only variables of the scope around them that the functions could capture
+func capturedNames(node ast.Node) map[string]bool {
+	names := make(map[string]bool)
+
+	ast.Inspect(node, func(n ast.Node) bool {
+		if _, ok := n.(*ast.FunctionLiteral); !ok {
+			return true
+		}
+
+		ast.Inspect(n, func(inner ast.Node) bool {
+			if ident, ok := inner.(*ast.Identifier); ok {
+				names[ident.Value] = true
+			}
+			return true
+		})
+		return false
+	})
+
+	return names
+}
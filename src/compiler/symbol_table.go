@@ -0,0 +1,178 @@
+package compiler
+
+import "sort"
+
+// SymbolScope is where the value of a variable is kept while the program runs
+type SymbolScope string
+
+// The scopes that a variable can be in
+const (
+	// GlobalScope variables are declared at the top level of the program
+	GlobalScope SymbolScope = "GLOBAL"
+	// LocalScope variables are parameters, variables declared inside of a function and
+	// variables declared inside of a loop, which are kept in the call frame of the function
+	LocalScope SymbolScope = "LOCAL"
+	// FreeScope variables are local variables of an enclosing function that a closure uses
+	FreeScope SymbolScope = "FREE"
+)
+
+// Symbol is a variable that the compiler has found the place of
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+	// Captured is true for a local variable that a closure might use, which is kept in a cell
+	// so that the function and the closure share it
+	Captured bool
+}
+
+// SymbolTable holds the variables declared in a scope. The program and each function have a
+// scope of their own, and so does each for loop, whose variables only exist inside the loop
+type SymbolTable struct {
+	Outer       *SymbolTable
+	FreeSymbols []Symbol
+
+	store map[string]Symbol
+	// declared holds the local variables that have been declared ahead of their declaration
+	// statement, which the scope itself can't use until the statement is reached
+	declared map[string]bool
+	free     map[string]Symbol
+	// function is the table of the function that the scope belongs to, which holds the local
+	// variables of all of the loops inside of the function. It is the table itself for the
+	// program and for functions
+	function   *SymbolTable
+	numGlobals int
+	localNames []string
+}
+
+// NewSymbolTable creates the symbol table of a program
+func NewSymbolTable() *SymbolTable {
+	s := &SymbolTable{store: make(map[string]Symbol), declared: make(map[string]bool), free: make(map[string]Symbol)}
+	s.function = s
+	return s
+}
+
+// NewEnclosedSymbolTable creates the symbol table of a function inside of the outer scope
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+	return s
+}
+
+// NewBlockSymbolTable creates the symbol table of a loop inside of the outer scope, whose
+// variables are kept alongside the variables of the function around it
+func NewBlockSymbolTable(outer *SymbolTable) *SymbolTable {
+	return &SymbolTable{
+		Outer:    outer,
+		store:    make(map[string]Symbol),
+		declared: make(map[string]bool),
+		free:     make(map[string]Symbol),
+		function: outer.function,
+	}
+}
+
+// Define declares a variable in the scope. Declaring a variable that has already been
+// declared in the same scope gives back the existing variable
+func (s *SymbolTable) Define(name string, captured bool) Symbol {
+	delete(s.declared, name)
+	return s.define(name, captured)
+}
+
+// Declare declares a local variable ahead of its declaration statement, so that it has the
+// same slot wherever it is used. Until the variable is defined, only the functions inside of
+// the scope can use it, and the scope itself uses the variable of the same name around it
+func (s *SymbolTable) Declare(name string, captured bool) Symbol {
+	if _, ok := s.store[name]; !ok && s.Outer != nil {
+		s.declared[name] = true
+	}
+	return s.define(name, captured)
+}
+
+func (s *SymbolTable) define(name string, captured bool) Symbol {
+	if symbol, ok := s.store[name]; ok {
+		return symbol
+	}
+
+	symbol := Symbol{Name: name, Captured: captured}
+	if s.Outer == nil {
+		symbol.Scope = GlobalScope
+		symbol.Index = s.numGlobals
+		s.numGlobals++
+	} else {
+		symbol.Scope = LocalScope
+		symbol.Index = len(s.function.localNames)
+		s.function.localNames = append(s.function.localNames, name)
+	}
+
+	s.store[name] = symbol
+	return symbol
+}
+
+// Resolve finds the variable that a name refers to, looking through the scopes around this
+// one. A local variable of an enclosing function becomes a free variable of this function
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	return s.resolve(name, false)
+}
+
+// resolve finds the variable that a name refers to. Once the search has left a function, the
+// variables that have only been declared ahead can be used as well, since the function can't
+// be called before they are defined
+func (s *SymbolTable) resolve(name string, inFunction bool) (Symbol, bool) {
+	if symbol, ok := s.store[name]; ok && (inFunction || !s.declared[name]) {
+		return symbol, true
+	}
+	if symbol, ok := s.free[name]; ok {
+		return symbol, true
+	}
+	if s.Outer == nil {
+		return Symbol{}, false
+	}
+
+	symbol, ok := s.Outer.resolve(name, inFunction || s.function == s)
+	if !ok || symbol.Scope == GlobalScope || s.function != s {
+		return symbol, ok
+	}
+
+	return s.defineFree(symbol), true
+}
+
+func (s *SymbolTable) defineFree(original Symbol) Symbol {
+	s.FreeSymbols = append(s.FreeSymbols, original)
+
+	symbol := Symbol{Name: original.Name, Scope: FreeScope, Index: len(s.FreeSymbols) - 1, Captured: true}
+	s.free[original.Name] = symbol
+	return symbol
+}
+
+// Locals returns the local variables declared directly in the scope, in the order they were
+// declared
+func (s *SymbolTable) Locals() []Symbol {
+	var locals []Symbol
+	for _, symbol := range s.store {
+		if symbol.Scope == LocalScope {
+			locals = append(locals, symbol)
+		}
+	}
+	sort.Slice(locals, func(i, j int) bool { return locals[i].Index < locals[j].Index })
+	return locals
+}
+
+// Global finds the symbol table of the program
+func (s *SymbolTable) Global() *SymbolTable {
+	for s.Outer != nil {
+		s = s.Outer
+	}
+	return s
+}
+
+// GlobalNames returns the names of the global variables, indexed by their symbol's index
+func (s *SymbolTable) GlobalNames() []string {
+	global := s.Global()
+	names := make([]string, global.numGlobals)
+	for name, symbol := range global.store {
+		if symbol.Scope == GlobalScope {
+			names[symbol.Index] = name
+		}
+	}
+	return names
+}
@@ -0,0 +1,121 @@
+package compiler
+
+import "testing"
+
+func TestDefine(t *testing.T) {
+	global := NewSymbolTable()
+	a := global.Define("a", false)
+	b := global.Define("b", false)
+
+	if a != (Symbol{Name: "a", Scope: GlobalScope, Index: 0}) || b != (Symbol{Name: "b", Scope: GlobalScope, Index: 1}) {
+		t.Errorf("wrong global symbols. got=%+v, %+v", a, b)
+	}
+
+	if again := global.Define("a", true); again != a {
+		t.Errorf("defining a variable twice gave a new symbol. expected=%+v, got=%+v", a, again)
+	}
+
+	local := NewEnclosedSymbolTable(global)
+	c := local.Define("c", true)
+	if c != (Symbol{Name: "c", Scope: LocalScope, Index: 0, Captured: true}) {
+		t.Errorf("wrong local symbol. got=%+v", c)
+	}
+
+	expected := []string{"a", "b"}
+	names := global.GlobalNames()
+	if len(names) != len(expected) || names[0] != expected[0] || names[1] != expected[1] {
+		t.Errorf("wrong global names. expected=%q, got=%q", expected, names)
+	}
+}
+
+func TestResolveFree(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a", false)
+
+	outer := NewEnclosedSymbolTable(global)
+	outer.Define("b", true)
+
+	inner := NewEnclosedSymbolTable(outer)
+	inner.Define("c", false)
+
+	tests := []struct {
+		name     string
+		expected Symbol
+	}{
+		{"a", Symbol{Name: "a", Scope: GlobalScope, Index: 0}},
+		{"b", Symbol{Name: "b", Scope: FreeScope, Index: 0, Captured: true}},
+		{"c", Symbol{Name: "c", Scope: LocalScope, Index: 0}},
+	}
+
+	for _, tt := range tests {
+		symbol, ok := inner.Resolve(tt.name)
+		if !ok {
+			t.Errorf("name %s not resolvable", tt.name)
+			continue
+		}
+		if symbol != tt.expected {
+			t.Errorf("wrong symbol for %s. expected=%+v, got=%+v", tt.name, tt.expected, symbol)
+		}
+	}
+
+	if len(inner.FreeSymbols) != 1 || inner.FreeSymbols[0].Scope != LocalScope {
+		t.Errorf("wrong free symbols. got=%+v", inner.FreeSymbols)
+	}
+
+	if _, ok := inner.Resolve("d"); ok {
+		t.Errorf("undeclared name d was resolved")
+	}
+}
+
+func TestBlockSymbolTable(t *testing.T) {
+	global := NewSymbolTable()
+	fn := NewEnclosedSymbolTable(global)
+	fn.Define("a", false)
+
+	block := NewBlockSymbolTable(fn)
+	b := block.Define("b", false)
+	if b != (Symbol{Name: "b", Scope: LocalScope, Index: 1}) {
+		t.Errorf("block variable doesn't use the next slot of its function. got=%+v", b)
+	}
+
+	if a, _ := block.Resolve("a"); a.Scope != LocalScope || a.Index != 0 {
+		t.Errorf("variable of the function isn't local inside of the block. got=%+v", a)
+	}
+
+	if _, ok := fn.Resolve("b"); ok {
+		t.Errorf("block variable can be resolved outside of the block")
+	}
+
+	locals := block.Locals()
+	if len(locals) != 1 || locals[0] != b {
+		t.Errorf("wrong block locals. got=%+v", locals)
+	}
+
+	topLevel := NewBlockSymbolTable(global)
+	if i := topLevel.Define("i", false); i.Scope != LocalScope || i.Index != 0 {
+		t.Errorf("loop variable at the top level isn't local. got=%+v", i)
+	}
+}
+
+func TestDeclare(t *testing.T) {
+	global := NewSymbolTable()
+	x := global.Define("x", false)
+
+	fn := NewEnclosedSymbolTable(global)
+	local := fn.Declare("x", true)
+	inner := NewEnclosedSymbolTable(fn)
+
+	if symbol, _ := fn.Resolve("x"); symbol != x {
+		t.Errorf("declared variable was used before it was defined. got=%+v", symbol)
+	}
+	if symbol, _ := inner.Resolve("x"); symbol.Scope != FreeScope || inner.FreeSymbols[0] != local {
+		t.Errorf("declared variable can't be used by a function inside of its scope. got=%+v", symbol)
+	}
+
+	if defined := fn.Define("x", false); defined != local {
+		t.Errorf("defining a declared variable gave a new symbol. expected=%+v, got=%+v", local, defined)
+	}
+	if symbol, _ := fn.Resolve("x"); symbol != local {
+		t.Errorf("defined variable wasn't used. got=%+v", symbol)
+	}
+}
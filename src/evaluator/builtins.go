@@ -2,11 +2,14 @@ package evaluator
 
 import (
 	"fmt"
+	"strconv"
+	"unicode/utf8"
 
 	"github.com/kai119/Brisk/src/evaluator/object"
 )
 
-var builtins = map[string]*object.Builtin{
+// Builtins are the functions that are available to every BRISK program
+var Builtins = map[string]*object.Builtin{
 	"len": {
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 1 {
@@ -15,7 +18,7 @@ var builtins = map[string]*object.Builtin{
 
 			switch arg := args[0].(type) {
 			case *object.String:
-				return &object.Integer{Value: int64(len(arg.Value))}
+				return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 			case *object.Array:
 				return &object.Integer{Value: int64(len(arg.Elements))}
 			default:
@@ -80,7 +83,7 @@ var builtins = map[string]*object.Builtin{
 	"push": {
 		Fn: func(args ...object.Object) object.Object {
 			if len(args) != 2 {
-				return newError("wrong number of arguments. got=%d, want=1", len(args))
+				return newError("wrong number of arguments. got=%d, want=2", len(args))
 			}
 			if args[0].Type() != object.ARRAY_OBJ {
 				return newError("argument to 'push' must be ARRAY, got %s", args[0].Type())
@@ -96,6 +99,68 @@ var builtins = map[string]*object.Builtin{
 			return &object.Array{Elements: newElements}
 		},
 	},
+	"int": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Integer:
+				return arg
+			case *object.Char:
+				return &object.Integer{Value: int64(arg.Value)}
+			case *object.Float:
+				return &object.Integer{Value: int64(arg.Value)}
+			case *object.String:
+				value, err := strconv.ParseInt(arg.Value, 10, 64)
+				if err != nil {
+					return newError("could not convert %q to INTEGER", arg.Value)
+				}
+				return &object.Integer{Value: value}
+			default:
+				return newError("argument to 'int' not supported, got %s", args[0].Type())
+			}
+		},
+	},
+	"char": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			switch arg := args[0].(type) {
+			case *object.Char:
+				return arg
+			case *object.Integer:
+				if arg.Value < 0 || arg.Value > utf8.MaxRune || !utf8.ValidRune(rune(arg.Value)) {
+					return newError("%d is not a valid character code point", arg.Value)
+				}
+				return &object.Char{Value: rune(arg.Value)}
+			case *object.String:
+				if utf8.RuneCountInString(arg.Value) != 1 {
+					return newError("could not convert %q to CHAR", arg.Value)
+				}
+				value, _ := utf8.DecodeRuneInString(arg.Value)
+				return &object.Char{Value: value}
+			default:
+				return newError("argument to 'char' not supported, got %s", args[0].Type())
+			}
+		},
+	},
+	"str": {
+		Fn: func(args ...object.Object) object.Object {
+			if len(args) != 1 {
+				return newError("wrong number of arguments. got=%d, want=1", len(args))
+			}
+
+			if arg, ok := args[0].(*object.String); ok {
+				return arg
+			}
+
+			return &object.String{Value: args[0].Inspect()}
+		},
+	},
 	"println": {
 		Fn: func(args ...object.Object) object.Object {
 			for _, arg := range args {
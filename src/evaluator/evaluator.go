@@ -2,109 +2,229 @@ package evaluator
 
 import (
 	"fmt"
+	"math"
 	"strings"
 
 	"github.com/kai119/Brisk/src/evaluator/object"
+	"github.com/kai119/Brisk/src/lexer/token"
 	"github.com/kai119/Brisk/src/parser/ast"
 )
 
 // Finite reference values
 var (
-	NULL  = &object.Null{}
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
+	NULL     = &object.Null{}
+	TRUE     = &object.Boolean{Value: true}
+	FALSE    = &object.Boolean{Value: false}
+	BREAK    = &object.Break{}
+	CONTINUE = &object.Continue{}
 )
 
-// Eval evaluates a node of a tree, returning the object representation
+// DefaultMaxCallDepth is the most function calls that can be running at once, unless the
+// options of an evaluation give a different limit. A call that would go deeper results in an
+// error instead of overflowing the Go stack. Tail calls take the place of the call that makes
+// them, so they don't add to the depth
+const DefaultMaxCallDepth = 10000
+
+// Options changes how programs are evaluated
+type Options struct {
+	// MaxCallDepth is the most function calls that can be running at once. Zero means
+	// DefaultMaxCallDepth
+	MaxCallDepth int
+}
+
+// Evaluator holds the state of an evaluation that lasts between function calls
+type Evaluator struct {
+	maxCallDepth int
+	// callStack holds the calls that are running, the most recent last
+	callStack []CallFrame
+}
+
+// New creates an evaluator with the options given
+func New(opts Options) *Evaluator {
+	maxCallDepth := opts.MaxCallDepth
+	if maxCallDepth <= 0 {
+		maxCallDepth = DefaultMaxCallDepth
+	}
+	return &Evaluator{maxCallDepth: maxCallDepth}
+}
+
+// Eval evaluates a node of a tree with the default options, returning the object
+// representation
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	return New(Options{}).Eval(node, env)
+}
+
+// Eval evaluates a node of a tree, returning the object representation. A program is resolved
+// before it is run, so that its variables can be found by their slot in the environment, and
+// the first error found by the resolver is returned without running any of the program. Any Go
+// panic that happens while evaluating is recovered and returned as an internal error, so that
+// a bug in the interpreter can't crash the program running it
+func (e *Evaluator) Eval(node ast.Node, env *object.Environment) (result object.Object) {
+	depth := len(e.callStack)
+	defer func() {
+		// a panic leaves the calls that it unwound on the call stack
+		e.callStack = e.callStack[:depth]
+		if r := recover(); r != nil {
+			result = newError("internal error: %v", r)
+		}
+	}()
+
+	if program, ok := node.(*ast.Program); ok {
+		if err := resolveProgram(program, env); err != nil {
+			return err
+		}
+	}
+
+	return e.evalNode(node, env)
+}
+
+// resolveProgram fills in the bindings of the identifiers in a program. The variables of the
+// environment can be used by the program as global variables
+func resolveProgram(program *ast.Program, env *object.Environment) *object.Error {
+	if err := Resolve(program, env.Names()); err != nil {
+		return &object.Error{Message: err.Message, Pos: err.Pos}
+	}
+	return nil
+}
+
+// evalNode evaluates a node of a tree. If evaluating the node results in an error that
+// has no position yet, the error is given the position of the node
+func (e *Evaluator) evalNode(node ast.Node, env *object.Environment) object.Object {
+	result := e.eval(node, env)
+
+	if errObj, ok := result.(*object.Error); ok && !errObj.Pos.IsValid() && node != nil {
+		errObj.Pos = node.Pos()
+	}
+
+	return result
+}
+
+func (e *Evaluator) eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
-		return evalProgram(node, env)
+		return e.evalProgram(node, env)
 	case *ast.ExpressionStatement:
-		return Eval(node.Expression, env)
+		return e.evalNode(node.Expression, env)
 
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.Boolean:
 		return nativeBoolToBooleanObj(node.Value)
 	case *ast.PrefixExpression:
-		right := Eval(node.Right, env)
+		right := e.evalNode(node.Right, env)
 		if isError(right) {
 			return right
 		}
 		return evalPrefixExpression(node.Operator, right)
 	case *ast.InfixExpression:
-		left := Eval(node.Left, env)
+		left := e.evalNode(node.Left, env)
 		if isError(left) {
 			return left
 		}
-		right := Eval(node.Right, env)
+		if isLogicalOperator(node.Token.Type) {
+			return e.evalLogicalExpression(node, left, env)
+		}
+		right := e.evalNode(node.Right, env)
 		if isError(right) {
 			return right
 		}
 		return evalInfixExpression(node.Operator, left, right)
 	case *ast.BlockStatement:
-		return evalBlockStatement(node, env)
+		return e.evalBlockStatement(node, env)
 	case *ast.IfExpression:
-		return evalIfExpression(node, env)
+		return e.evalIfExpression(node, env)
+	case *ast.WhileStatement:
+		return e.evalWhileStatement(node, env)
+	case *ast.ForStatement:
+		return e.evalForStatement(node, env)
+	case *ast.ForInStatement:
+		return e.evalForInStatement(node, env)
+	case *ast.BreakStatement:
+		return BREAK
+	case *ast.ContinueStatement:
+		return CONTINUE
 	case *ast.ReturnStatement:
-		val := Eval(node.ReturnValue, env)
+		val := e.evalNode(node.ReturnValue, env)
 		if isError(val) {
 			return val
 		}
 		return &object.ReturnValue{Value: val}
 	case *ast.VarStatement:
-		val := Eval(node.Value, env)
-		if isError(val) {
-			return val
-		}
-		env.Set(node.Name.Value, val)
+		return e.evalVarStatement(node, env)
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Env: env, Body: body}
+		return &object.Function{
+			Name:           node.Name,
+			Parameters:     params,
+			ParameterTypes: node.ParameterTypes,
+			ReturnType:     node.ReturnType,
+			Env:            env,
+			Body:           body,
+			Slots:          node.Slots,
+		}
+	case *ast.FunctionStatement:
+		// named functions are bound by hoistFunctions before the statements around them are run
+		return nil
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
+		function := e.evalNode(node.Function, env)
 		if isError(function) {
 			return function
 		}
-		args := evalExpressions(node.Arguments, env)
+		args := e.evalExpressions(node.Arguments, env)
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
-		return applyFunction(function, args)
+		if node.Tail {
+			return &object.TailCall{Function: function, Arguments: args, Pos: node.Pos()}
+		}
+		return e.applyFunction(function, args, node.Pos())
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.CharLiteral:
+		return &object.Char{Value: node.Value}
 	case *ast.ArrayLiteral:
-		elements := evalExpressions(node.Elements, env)
+		elements := e.evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
 			return elements[0]
 		}
 		return &object.Array{Elements: elements}
+	case *ast.TupleLiteral:
+		elements := e.evalExpressions(node.Elements, env)
+		if len(elements) == 1 && isError(elements[0]) {
+			return elements[0]
+		}
+		return &object.Tuple{Elements: elements}
 	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
+		left := e.evalNode(node.Left, env)
 		if isError(left) {
 			return left
 		}
-		index := Eval(node.Index, env)
+		index := e.evalNode(node.Index, env)
 		if isError(index) {
 			return index
 		}
 		return evalIndexExpression(left, index)
 	case *ast.DictionaryLiteral:
-		return evalDictionaryLiteral(node, env)
+		return e.evalDictionaryLiteral(node, env)
+	case *ast.AssignExpression:
+		return e.evalAssignExpression(node, env)
 	}
 
 	return nil
 }
 
-func evalProgram(program *ast.Program, env *object.Environment) object.Object {
+func (e *Evaluator) evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	var result object.Object
 
+	hoistFunctions(program.Statements, env)
+
 	for _, statement := range program.Statements {
-		result = Eval(statement, env)
+		result = e.evalNode(statement, env)
 
 		switch result := result.(type) {
 		case *object.ReturnValue:
@@ -117,15 +237,17 @@ func evalProgram(program *ast.Program, env *object.Environment) object.Object {
 	return result
 }
 
-func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+func (e *Evaluator) evalBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
 	var result object.Object
 
+	hoistFunctions(block.Statements, env)
+
 	for _, statement := range block.Statements {
-		result = Eval(statement, env)
+		result = e.evalNode(statement, env)
 
 		if result != nil {
 			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ || rt == object.BREAK_OBJ || rt == object.CONTINUE_OBJ {
 				return result
 			}
 		}
@@ -134,6 +256,65 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 	return result
 }
 
+func (e *Evaluator) evalVarStatement(node *ast.VarStatement, env *object.Environment) object.Object {
+	val := e.evalNode(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	if node.Destructure != ast.NoDestructure {
+		return evalDestructure(node, val, env)
+	}
+
+	if node.Type != nil && !IsOfType(val, node.Type.Name) {
+		return newError("wrong type for variable %s: want=%s, got=%s", node.Name.Value, node.Type, val.Type())
+	}
+	setVariable(node.Name, val, env)
+
+	return nil
+}
+
+func evalDestructure(node *ast.VarStatement, val object.Object, env *object.Environment) object.Object {
+	names := make([]string, len(node.Names))
+	for i, name := range node.Names {
+		names[i] = name.Value
+	}
+
+	values, err := Unpack(node.Destructure, names, val)
+	if err != nil {
+		return err
+	}
+
+	for i, name := range node.Names {
+		setVariable(name, values[i], env)
+	}
+
+	return nil
+}
+
+// hoistFunctions binds every function declared in the statements before any of them are run,
+// so that functions can be called before they are declared and can call each other
+func hoistFunctions(statements []ast.Statement, env *object.Environment) {
+	for _, statement := range statements {
+		if fs, ok := statement.(*ast.FunctionStatement); ok {
+			declareFunction(fs, env)
+		}
+	}
+}
+
+func declareFunction(fs *ast.FunctionStatement, env *object.Environment) {
+	fn := fs.Function
+	setVariable(fs.Name, &object.Function{
+		Name:           fn.Name,
+		Parameters:     fn.Parameters,
+		ParameterTypes: fn.ParameterTypes,
+		ReturnType:     fn.ReturnType,
+		Env:            env,
+		Body:           fn.Body,
+		Slots:          fn.Slots,
+	}, env)
+}
+
 func nativeBoolToBooleanObj(input bool) *object.Boolean {
 	if input {
 		return TRUE
@@ -166,19 +347,30 @@ func evalNotOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
+	switch right := right.(type) {
+	case *object.Integer:
+		return &object.Integer{Value: -right.Value}
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
 		return newError("unknown operator: -%s", right.Type())
 	}
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
 }
 
 func evalInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalIntegerInfixExpression(operator, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
+	case left.Type() == object.CHAR_OBJ && right.Type() == object.CHAR_OBJ:
+		return evalCharInfixExpression(operator, left, right)
+	case left.Type() == object.CHAR_OBJ && right.Type() == object.STRING_OBJ:
+		return evalStringInfixExpression(operator, &object.String{Value: left.Inspect()}, right)
+	case left.Type() == object.STRING_OBJ && right.Type() == object.CHAR_OBJ:
+		return evalStringInfixExpression(operator, left, &object.String{Value: right.Inspect()})
 	case left.Type() == object.STRING_OBJ && right.Type() == object.INTEGER_OBJ:
 		return evalStringRepeatExpression(operator, left, right)
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.STRING_OBJ:
@@ -194,6 +386,27 @@ func evalInfixExpression(operator string, left object.Object, right object.Objec
 	}
 }
 
+func isLogicalOperator(tokenType token.Type) bool {
+	return tokenType == token.LOGICAL_AND || tokenType == token.LOGICAL_OR
+}
+
+// evalLogicalExpression evaluates 'and' and 'or' expressions, which can also be written as
+// '&&' and '||'. The right side is only evaluated if the left side doesn't already decide
+// the result, so 'false and x' and 'true or x' never evaluate x
+func (e *Evaluator) evalLogicalExpression(node *ast.InfixExpression, left object.Object, env *object.Environment) object.Object {
+	isAnd := node.Token.Type == token.LOGICAL_AND
+	if isTruthy(left) != isAnd {
+		return nativeBoolToBooleanObj(!isAnd)
+	}
+
+	right := e.evalNode(node.Right, env)
+	if isError(right) {
+		return right
+	}
+
+	return nativeBoolToBooleanObj(isTruthy(right))
+}
+
 func evalIntegerInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 	leftVal := left.(*object.Integer).Value
 	rightVal := right.(*object.Integer).Value
@@ -206,11 +419,23 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 	case "*":
 		return &object.Integer{Value: leftVal * rightVal}
 	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
 		return &object.Integer{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("modulo by zero")
+		}
+		return &object.Integer{Value: leftVal % rightVal}
 	case "<":
 		return nativeBoolToBooleanObj(leftVal < rightVal)
 	case ">":
 		return nativeBoolToBooleanObj(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObj(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObj(leftVal >= rightVal)
 	case "==":
 		return nativeBoolToBooleanObj(leftVal == rightVal)
 	case "!=":
@@ -220,11 +445,49 @@ func evalIntegerInfixExpression(operator string, left object.Object, right objec
 	}
 }
 
-func evalStringInfixExpression(operator string, left object.Object, right object.Object) object.Object {
-	if !strings.Contains("+*==!=", operator) {
-		return newError("unknown operator %s %s %s", left.Type(), operator, right.Type())
+// evalFloatInfixExpression evaluates an infix expression where at least one side is a float,
+// converting an integer on the other side to a float first
+func evalFloatInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		if rightVal == 0 {
+			return newError("division by zero")
+		}
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		if rightVal == 0 {
+			return newError("modulo by zero")
+		}
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case "<":
+		return nativeBoolToBooleanObj(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObj(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObj(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObj(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObj(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObj(leftVal != rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
+}
 
+// evalStringInfixExpression evaluates an infix expression between two strings. Strings are
+// ordered lexicographically, so "apple" < "banana" and "app" < "apple"
+func evalStringInfixExpression(operator string, left object.Object, right object.Object) object.Object {
 	leftVal := left.(*object.String).Value
 	rightVal := right.(*object.String).Value
 
@@ -235,55 +498,228 @@ func evalStringInfixExpression(operator string, left object.Object, right object
 		return nativeBoolToBooleanObj(leftVal == rightVal)
 	case "!=":
 		return nativeBoolToBooleanObj(leftVal != rightVal)
+	case "<":
+		return nativeBoolToBooleanObj(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObj(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObj(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObj(leftVal >= rightVal)
 	default:
 		return newError("unknown operator %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
+// evalCharInfixExpression evaluates an infix expression between two characters, which are
+// ordered by their Unicode code points
+func evalCharInfixExpression(operator string, left object.Object, right object.Object) object.Object {
+	leftVal := left.(*object.Char).Value
+	rightVal := right.(*object.Char).Value
+
+	switch operator {
+	case "+":
+		return &object.String{Value: string(leftVal) + string(rightVal)}
+	case "==":
+		return nativeBoolToBooleanObj(leftVal == rightVal)
+	case "!=":
+		return nativeBoolToBooleanObj(leftVal != rightVal)
+	case "<":
+		return nativeBoolToBooleanObj(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObj(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObj(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObj(leftVal >= rightVal)
+	default:
+		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
 func evalStringRepeatExpression(operator string, left object.Object, right object.Object) object.Object {
 	leftVal := left.(*object.String).Value
 	rightVal := right.(*object.Integer).Value
 
 	switch operator {
 	case "*":
+		if rightVal < 0 {
+			return newError("negative repeat count: %d", rightVal)
+		}
 		return &object.String{Value: strings.Repeat(leftVal, int(rightVal))}
 	default:
 		return newError("unknown operator %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
-func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
-	condition := Eval(ie.Condition, env)
+func (e *Evaluator) evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+	condition := e.evalNode(ie.Condition, env)
 	if isError(condition) {
 		return condition
 	}
 
 	if isTruthy(condition) {
-		return Eval(ie.Consequence, env)
+		return e.evalNode(ie.Consequence, env)
 	} else if ie.Alternative != nil {
-		return Eval(ie.Alternative, env)
+		return e.evalNode(ie.Alternative, env)
 	} else {
 		return NULL
 	}
 }
 
+func (e *Evaluator) evalWhileStatement(ws *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := e.evalNode(ws.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+
+		if !isTruthy(condition) {
+			return NULL
+		}
+
+		if result, stop := e.evalLoopBody(ws.Body, env); stop {
+			return result
+		}
+	}
+}
+
+// evalForStatement evaluates a C-style for loop. The loop gets its own environment so that
+// variables declared in the init statement only exist inside of the loop
+func (e *Evaluator) evalForStatement(fs *ast.ForStatement, env *object.Environment) object.Object {
+	loopEnv := object.NewSlotEnvironment(env, fs.Slots)
+
+	if fs.Init != nil {
+		init := e.evalNode(fs.Init, loopEnv)
+		if isError(init) {
+			return init
+		}
+	}
+
+	for {
+		if fs.Condition != nil {
+			condition := e.evalNode(fs.Condition, loopEnv)
+			if isError(condition) {
+				return condition
+			}
+
+			if !isTruthy(condition) {
+				return NULL
+			}
+		}
+
+		if result, stop := e.evalLoopBody(fs.Body, loopEnv); stop {
+			return result
+		}
+
+		if fs.Post != nil {
+			post := e.evalNode(fs.Post, loopEnv)
+			if isError(post) {
+				return post
+			}
+		}
+	}
+}
+
+// evalForInStatement evaluates a loop over the elements of an array, the characters of a
+// string or the keys of a dictionary, binding each one to the loop variable in the loop's
+// own environment
+func (e *Evaluator) evalForInStatement(fi *ast.ForInStatement, env *object.Environment) object.Object {
+	iterable := e.evalNode(fi.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	elements, err := Elements(iterable)
+	if err != nil {
+		return err
+	}
+
+	loopEnv := object.NewSlotEnvironment(env, fi.Slots)
+
+	for _, element := range elements {
+		setVariable(fi.Variable, element, loopEnv)
+
+		if result, stop := e.evalLoopBody(fi.Body, loopEnv); stop {
+			return result
+		}
+	}
+
+	return NULL
+}
+
+// evalLoopBody evaluates one iteration of a loop. It returns true when the loop has to stop,
+// along with the result the loop should give, either because of a break statement or because
+// a return value or error has to be passed up out of the loop
+func (e *Evaluator) evalLoopBody(body *ast.BlockStatement, env *object.Environment) (object.Object, bool) {
+	result := e.evalNode(body, env)
+	if result == nil {
+		return nil, false
+	}
+
+	switch result.Type() {
+	case object.BREAK_OBJ:
+		return NULL, true
+	case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+		return result, true
+	default:
+		return nil, false
+	}
+}
+
 func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object {
-	if val, ok := env.Get(node.Value); ok {
+	if val, ok := getVariable(node, env); ok {
 		return val
 	}
 
-	if builtin, ok := builtins[node.Value]; ok {
+	if builtin, ok := Builtins[node.Value]; ok {
 		return builtin
 	}
 
 	return newError("identifier not found: " + node.Value)
 }
 
-func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
+// getVariable finds the value of the variable that an identifier refers to. Resolved local
+// variables are found by their slot, while global variables and identifiers that haven't been
+// resolved are looked up by name
+func getVariable(node *ast.Identifier, env *object.Environment) (object.Object, bool) {
+	switch binding := node.Binding; {
+	case binding == nil:
+		return env.Get(node.Value)
+	case binding.Slot < 0:
+		return env.Ancestor(binding.Depth).Get(node.Value)
+	default:
+		return env.GetAt(binding.Depth, binding.Slot)
+	}
+}
+
+// setVariable declares the variable that an identifier refers to in the environment
+func setVariable(node *ast.Identifier, value object.Object, env *object.Environment) {
+	if node.Binding != nil && node.Binding.Slot >= 0 {
+		env.SetAt(node.Binding.Slot, value)
+		return
+	}
+	env.Set(node.Value, value)
+}
+
+// assignVariable updates the variable that an identifier refers to, returning false if the
+// variable hasn't been declared yet
+func assignVariable(node *ast.Identifier, value object.Object, env *object.Environment) (object.Object, bool) {
+	switch binding := node.Binding; {
+	case binding == nil:
+		return env.Assign(node.Value, value)
+	case binding.Slot < 0:
+		return env.Ancestor(binding.Depth).Assign(node.Value, value)
+	default:
+		return env.AssignAt(binding.Depth, binding.Slot, value)
+	}
+}
+
+func (e *Evaluator) evalExpressions(exps []ast.Expression, env *object.Environment) []object.Object {
 	var result []object.Object
 
-	for _, e := range exps {
-		evaluated := Eval(e, env)
+	for _, exp := range exps {
+		evaluated := e.evalNode(exp, env)
 		if isError(evaluated) {
 			return []object.Object{evaluated}
 		}
@@ -297,6 +733,8 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
 	case left.Type() == object.DICTIONARY_OBJ:
 		return evalDictionaryIndexExpression(left, index)
 	default:
@@ -316,11 +754,25 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 	return arrayObject.Elements[idx]
 }
 
-func evalDictionaryLiteral(node *ast.DictionaryLiteral, env *object.Environment) object.Object {
+// evalStringIndexExpression gets the Char at an index of a string. Strings are indexed
+// by character rather than by byte, so multi-byte characters count as a single index
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	chars := []rune(str.(*object.String).Value)
+	idx := index.(*object.Integer).Value
+	max := int64(len(chars) - 1)
+
+	if idx < 0 || idx > max {
+		return NULL
+	}
+
+	return &object.Char{Value: chars[idx]}
+}
+
+func (e *Evaluator) evalDictionaryLiteral(node *ast.DictionaryLiteral, env *object.Environment) object.Object {
 	pairs := make(map[object.DictionaryKey]object.DictionaryPair)
 
 	for keyNode, valueNode := range node.Pairs {
-		key := Eval(keyNode, env)
+		key := e.evalNode(keyNode, env)
 		if isError(key) {
 			return key
 		}
@@ -330,7 +782,7 @@ func evalDictionaryLiteral(node *ast.DictionaryLiteral, env *object.Environment)
 			return newError("unusable as hash key: %s", key.Type())
 		}
 
-		value := Eval(valueNode, env)
+		value := e.evalNode(valueNode, env)
 		if isError(value) {
 			return value
 		}
@@ -358,12 +810,139 @@ func evalDictionaryIndexExpression(dict, index object.Object) object.Object {
 	return pair.Value
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+// evalAssignExpression evaluates an assignment to an existing variable or to an index of an
+// array or dictionary. Compound assignments such as 'x += 1' apply the operator to the current
+// value first. The result of the expression is the value that was assigned
+func (e *Evaluator) evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	value := e.evalNode(node.Value, env)
+	if isError(value) {
+		return value
+	}
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		if node.Operator != "=" {
+			current := evalIdentifier(target, env)
+			if isError(current) {
+				return current
+			}
+			value = evalCompoundAssignment(node.Operator, current, value)
+			if isError(value) {
+				return value
+			}
+		}
+
+		if _, ok := assignVariable(target, value, env); !ok {
+			return newError("cannot assign to undeclared variable: %s", target.Value)
+		}
+		return value
+	case *ast.IndexExpression:
+		left := e.evalNode(target.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := e.evalNode(target.Index, env)
+		if isError(index) {
+			return index
+		}
+
+		if node.Operator != "=" {
+			current := evalIndexExpression(left, index)
+			if isError(current) {
+				return current
+			}
+			value = evalCompoundAssignment(node.Operator, current, value)
+			if isError(value) {
+				return value
+			}
+		}
+
+		return evalIndexAssignment(left, index, value)
+	default:
+		return newError("cannot assign to %s", node.Target.String())
+	}
+}
+
+func evalCompoundAssignment(operator string, current, value object.Object) object.Object {
+	return evalInfixExpression(strings.TrimSuffix(operator, "="), current, value)
+}
+
+func evalIndexAssignment(left, index, value object.Object) object.Object {
+	switch left := left.(type) {
+	case *object.Array:
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError("array index must be INTEGER, got %s", index.Type())
+		}
+		if idx.Value < 0 || idx.Value >= int64(len(left.Elements)) {
+			return newError("index out of range: %d", idx.Value)
+		}
+		left.Elements[idx.Value] = value
+		return value
+	case *object.Dictionary:
+		key, ok := index.(object.Hashable)
+		if !ok {
+			return newError("unusable as hash key: %s", index.Type())
+		}
+		left.Pairs[key.DictionaryKey()] = object.DictionaryPair{Key: index, Value: value}
+		return value
+	default:
+		return newError("index assignment not supported: %s", left.Type())
+	}
+}
+
+// applyFunction calls a function with the arguments given from the position of the call. A
+// function body that ends in a tail call gives back the call instead of making it, and the
+// call is then made here in a loop, so that a chain of tail calls runs in constant Go stack
+// space. The return types of the functions in the chain are checked once the last call has a
+// result, innermost first, as they would be if each call had been made inside the one before it
+func (e *Evaluator) applyFunction(fn object.Object, args []object.Object, pos token.Position) object.Object {
+	var pending []pendingReturn
+
+	for {
+		result := e.callFunction(fn, args, pos)
+
+		if err, ok := result.(*object.Error); ok {
+			if !err.Pos.IsValid() {
+				err.Pos = pos
+			}
+			return err
+		}
+
+		if function, ok := fn.(*object.Function); ok && function.ReturnType != nil {
+			pending = addPendingReturn(pending, function.ReturnType, pos)
+		}
+
+		tailCall, ok := result.(*object.TailCall)
+		if !ok {
+			return checkReturnTypes(result, pending)
+		}
+		fn, args, pos = tailCall.Function, tailCall.Arguments, tailCall.Pos
+	}
+}
+
+// callFunction makes a single call of a function from the position given, which results in a
+// tail call if the body of the function ends in one. The call is on the call stack while the
+// body of the function runs
+func (e *Evaluator) callFunction(fn object.Object, args []object.Object, pos token.Position) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
-		evaluated := Eval(fn.Body, extendedEnv)
-		return unwrapReturnValue(evaluated)
+		if len(e.callStack) >= e.maxCallDepth {
+			return CallDepthError(e.maxCallDepth, e.callStack)
+		}
+		if len(args) != len(fn.Parameters) {
+			return newError("wrong number of arguments: want=%d, got=%d", len(fn.Parameters), len(args))
+		}
+		extendedEnv, err := extendFunctionEnv(fn, args)
+		if err != nil {
+			return err
+		}
+
+		e.callStack = append(e.callStack, CallFrame{Name: fn.Name, Pos: pos})
+		result := unwrapReturnValue(e.evalNode(fn.Body, extendedEnv))
+		e.callStack = e.callStack[:len(e.callStack)-1]
+
+		return result
 	case *object.Builtin:
 		return fn.Fn(args...)
 	default:
@@ -371,24 +950,95 @@ func applyFunction(fn object.Object, args []object.Object) object.Object {
 	}
 }
 
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
-	env := object.NewEnclosedEnvironment(fn.Env)
+// pendingReturn is the return type of a function whose result is the result of a tail call,
+// along with the position of the call of the function
+type pendingReturn struct {
+	returnType *ast.Type
+	pos        token.Position
+}
+
+// addPendingReturn adds a return type to be checked once a chain of tail calls has a result.
+// Checking the same type as the last one again would give the same result, so instead only
+// the position of the innermost call is kept for the error, and typed tail recursion doesn't
+// use any more memory
+func addPendingReturn(pending []pendingReturn, returnType *ast.Type, pos token.Position) []pendingReturn {
+	if last := len(pending) - 1; last >= 0 && pending[last].returnType.Name == returnType.Name {
+		pending[last].pos = pos
+		return pending
+	}
+	return append(pending, pendingReturn{returnType, pos})
+}
+
+// checkReturnTypes checks the result of a chain of tail calls against the return types of the
+// functions in the chain, starting with the last function called
+func checkReturnTypes(result object.Object, pending []pendingReturn) object.Object {
+	for i := len(pending) - 1; i >= 0; i-- {
+		if !IsOfType(result, pending[i].returnType.Name) {
+			err := newError("wrong return type: want=%s, got=%s", pending[i].returnType, result.Type())
+			err.Pos = pending[i].pos
+			return err
+		}
+	}
+	return result
+}
+
+// extendFunctionEnv binds the arguments of a function call to the function's parameters,
+// returning an error if an argument doesn't match the type of its parameter
+func extendFunctionEnv(fn *object.Function, args []object.Object) (*object.Environment, *object.Error) {
+	env := object.NewSlotEnvironment(fn.Env, fn.Slots)
 
 	for paramIdx, param := range fn.Parameters {
-		env.Set(param.Value, args[paramIdx])
+		if paramIdx < len(fn.ParameterTypes) {
+			paramType := fn.ParameterTypes[paramIdx]
+			if paramType != nil && !IsOfType(args[paramIdx], paramType.Name) {
+				return nil, newError("wrong type for parameter %s: want=%s, got=%s", param.Value, paramType, args[paramIdx].Type())
+			}
+		}
+		setVariable(param, args[paramIdx], env)
 	}
 
-	return env
+	return env, nil
 }
 
+// typeObjects maps the names of the BRISK types to the type of object that they hold
+var typeObjects = map[string]object.Type{
+	token.TYPE_INT:    object.INTEGER_OBJ,
+	token.TYPE_STR:    object.STRING_OBJ,
+	token.TYPE_BOOL:   object.BOOLEAN_OBJ,
+	token.TYPE_DOUBLE: object.FLOAT_OBJ,
+	token.TYPE_FLOAT:  object.FLOAT_OBJ,
+	token.TYPE_CHAR:   object.CHAR_OBJ,
+}
+
+// unwrapReturnValue gets the value that a function call results in. A function with an
+// empty body, or one that ends in a statement with no value, results in null
 func unwrapReturnValue(obj object.Object) object.Object {
 	if returnValue, ok := obj.(*object.ReturnValue); ok {
 		return returnValue.Value
 	}
 
+	if obj == nil {
+		return NULL
+	}
+
 	return obj
 }
 
+func isNumber(obj object.Object) bool {
+	return obj.Type() == object.INTEGER_OBJ || obj.Type() == object.FLOAT_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.Float:
+		return obj.Value
+	default:
+		return 0
+	}
+}
+
 func isTruthy(obj object.Object) bool {
 	switch obj {
 	case NULL:
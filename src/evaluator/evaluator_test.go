@@ -1,11 +1,15 @@
-package evaluator
+package evaluator_test
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/kai119/Brisk/src/compiler"
+	"github.com/kai119/Brisk/src/evaluator"
 	"github.com/kai119/Brisk/src/evaluator/object"
 	"github.com/kai119/Brisk/src/lexer"
 	"github.com/kai119/Brisk/src/parser"
+	"github.com/kai119/Brisk/src/vm"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -17,6 +21,9 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"10", 10},
 		{"-5", -5},
 		{"-10", -10},
+		{"0x10 + 0o10 + 0b10", 26},
+		{"1_000 * 2", 2000},
+		{"100 + 0", 100},
 		{"5 + 5 + 5 + 5 - 10", 10},
 		{"2 * 2 * 2 * 2 * 2", 32},
 		{"-50 + 100 + -50", 0},
@@ -25,18 +32,63 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"20 + 2 * -10", 0},
 		{"50 / 2 * 2 + 10", 60},
 		{"2 * (5 + 10)", 30},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"2 + 10 % 4 * 3", 8},
+		{"var a = 10; a %= 4; a", 2},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testIntegerObject(t, evaluated, tt.expected)
 	}
 }
 
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14", 3.14},
+		{"-2.5", -2.5},
+		{"1e-9", 1e-9},
+		{"0.5 + 0.25", 0.75},
+		{"5.5 % 2", 1.5},
+		{"1.5 * 2", 3},
+		{"2 * 1.5", 3},
+		{"7 / 2.0", 3.5},
+		{"1 - 0.5", 0.5},
+		{"-(1.5 + 1)", -2.5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		testFloatObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"3.14", "3.14"},
+		{"1.5 * 2", "3.0"},
+		{"1e21", "1e+21"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong inspect output. expected=%q, got=%q", tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
 func TestStringLiteral(t *testing.T) {
 	input := `"hello world"`
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	str, ok := evaluated.(*object.String)
 	if !ok {
 		t.Fatalf("object is not string. got=%T (%+v)", evaluated, evaluated)
@@ -50,7 +102,7 @@ func TestStringLiteral(t *testing.T) {
 func TestStringConcatenation(t *testing.T) {
 	input := `"hello" + " " + "world"`
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	str, ok := evaluated.(*object.String)
 	if !ok {
 		t.Fatalf("object is not string. got=%T (%+v)", evaluated, evaluated)
@@ -64,7 +116,7 @@ func TestStringConcatenation(t *testing.T) {
 func TestStringRepeat(t *testing.T) {
 	input := `"hello" * 3`
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	str, ok := evaluated.(*object.String)
 	if !ok {
 		t.Fatalf("object is not string. got=%T (%+v)", evaluated, evaluated)
@@ -99,10 +151,55 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"(1 > 2) == false", true},
 		{`"hello" == "hello"`, true},
 		{`"hello" != "hello"`, false},
+		{"1.5 < 2", true},
+		{"2 > 1.5", true},
+		{"1.0 == 1", true},
+		{"0.1 + 0.2 != 0.3", true},
+		{"2.5 > 2.5", false},
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{"2.5 >= 2", true},
+		{"2 <= 1.5", false},
+		{`"apple" < "banana"`, true},
+		{`"app" < "apple"`, true},
+		{`"b" > "a"`, true},
+		{`"abc" <= "abc"`, true},
+		{`"abc" >= "abd"`, false},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
+		testBooleanObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestLogicalOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"true and true", true},
+		{"true and false", false},
+		{"false and true", false},
+		{"false or true", true},
+		{"false or false", false},
+		{"true && true", true},
+		{"false || true", true},
+		{"1 < 2 and 2 < 3", true},
+		{"1 > 2 or 2 > 3", false},
+		{"1 and 0", true},
+		{`"a" == "b" or 1 == 1 and 2 == 2`, true},
+		{"var zero = 0; false and 1 / zero", false},
+		{"var zero = 0; true or 1 / zero", true},
+		{"var called = false; var f = func() { called = true; }; false and f(); called", false},
+		{"var called = false; var f = func() { called = true; }; true and f(); called", true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
 		testBooleanObject(t, evaluated, tt.expected)
 	}
 }
@@ -121,7 +218,7 @@ func TestNotOperator(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testBooleanObject(t, evaluated, tt.expected)
 	}
 }
@@ -141,7 +238,7 @@ func TestIfElseExpressions(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		integer, ok := tt.expected.(int)
 		if ok {
 			testIntegerObject(t, evaluated, int64(integer))
@@ -173,11 +270,42 @@ func TestReturnStatements(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		testIntegerObject(t, evaluated, tt.expected)
 	}
 }
 
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"while (false) { 10 }", nil},
+		{"while (true) { break; }", nil},
+		{"var f = func() { while (true) { return 5; } }; f();", 5},
+		{"var f = func() { for (var i = 7; i > 5; i) { return i; } }; f();", 7},
+		{"for (var i = 0; i > 5; i) { 10 }", nil},
+		{"var f = func() { for (x in [1, 2, 3]) { if (x > 1) { return x; } } }; f();", 2},
+		{"var f = func() { for (x in [1, 2, 3]) { if (x < 3) { continue; } return x; } }; f();", 3},
+		{"var f = func() { for (x in [1, 2, 3]) { break; } return 4; }; f();", 4},
+		{`var f = func() { for (c in "abc") { if (c == 'b') { return int(c); } } }; f();`, 98},
+		{`var f = func() { for (k in {"a": 1}) { return {"a": 2}[k]; } }; f();`, 2},
+		{`var n = 0; for (k in {3: "c", 1: "a", 4: "d", 2: "b"}) { n = n * 10 + k }; n`, 1234},
+		{"var f = func() { while (true) { for (;;) { break; } return 6; } }; f();", 6},
+		{"for (x in []) { x }", nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		integer, ok := tt.expected.(int)
+		if ok {
+			testIntegerObject(t, evaluated, int64(integer))
+		} else {
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
 func TestErrorHandling(t *testing.T) {
 	tests := []struct {
 		input           string
@@ -231,10 +359,62 @@ if (10 > 1) {
 			`{"name": "test"}[func(x) { x }];`,
 			"unusable as hash key: FUNCTION",
 		},
+		{
+			"for (x in 5) { x }",
+			"cannot iterate over INTEGER",
+		},
+		{
+			"true and missing",
+			"identifier not found: missing",
+		},
+		{
+			"10 / 0",
+			"division by zero",
+		},
+		{
+			"var a = 0; 10 % a",
+			"modulo by zero",
+		},
+		{
+			"1.0 / 0",
+			"division by zero",
+		},
+		{
+			"var a = 0.0; 5 / a",
+			"division by zero",
+		},
+		{
+			"1.5 % 0",
+			"modulo by zero",
+		},
+		{
+			"-2.5 % -0.0",
+			"modulo by zero",
+		},
+		{
+			`"a" % "b"`,
+			"unknown operator STRING % STRING",
+		},
+		{
+			"var add = func(a, b) { a + b }; add(1);",
+			"wrong number of arguments: want=2, got=1",
+		},
+		{
+			"var one = func(a) { a }; one(1, 2);",
+			"wrong number of arguments: want=1, got=2",
+		},
+		{
+			`"ab" * -1`,
+			"negative repeat count: -1",
+		},
+		{
+			"while (true) { 1 + true }",
+			"type mismatch: INTEGER + BOOLEAN",
+		},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 
 		errObj, ok := evaluated.(*object.Error)
 		if !ok {
@@ -248,6 +428,191 @@ if (10 > 1) {
 	}
 }
 
+func TestTypedDeclarations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"var x int = 5; x;", 5},
+		{`var s str = "hi"; len(s);`, 2},
+		{"var f float = 1.5; f > 1;", true},
+		{"var d double = 0.5; d < 1;", true},
+		{"var b bool = !true; b;", false},
+		{"var c char = 'a'; int(c);", 97},
+		{"var add = func(a int, b int) int { a + b }; add(1, 2);", 3},
+		{"func half(n float, label) float { return n / 2.0; } half(3.0, 1) == 1.5;", true},
+		{`var x int = "five";`, "wrong type for variable x: want=int, got=STRING"},
+		{"var f float = 1;", "wrong type for variable f: want=float, got=INTEGER"},
+		{"var add = func(a int, b int) { a + b }; add(1, true);", "wrong type for parameter b: want=int, got=BOOLEAN"},
+		{`var name = func() str { 5 }; name();`, "wrong return type: want=str, got=INTEGER"},
+		{`func nothing() int { } nothing();`, "wrong return type: want=int, got=NULL"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestMultipleReturnValues(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"func pair() { return 1, 2; } var x, y = pair(); x * 10 + y;", 12},
+		{"func swap(a, b) { return b, a; } var a, b = swap(1, 2); a - b;", 1},
+		{"var [a, b, c] = [1, 2, 3]; a + b + c;", 6},
+		{`var {name, age} = {"name": 3, "age": 4, "other": 5}; name * age;`, 12},
+		{"func pair() { return 1, 2; } pair();", "(1, 2)"},
+		{"func pair() { return 1, 2; } var x, y, z = pair();", "wrong number of values to unpack: want=3, got=2"},
+		{"var x, y = 5;", "wrong number of values to unpack: want=2, got=1"},
+		{"var [a, b] = [1, 2, 3];", "wrong number of values to unpack: want=2, got=3"},
+		{"var [a] = 1;", "cannot destructure INTEGER as an array"},
+		{`var {name} = {"age": 1};`, "key not found in dictionary: name"},
+		{"var {name} = [1];", "cannot destructure ARRAY as a dictionary"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if tuple, ok := evaluated.(*object.Tuple); ok {
+				if tuple.Inspect() != expected {
+					t.Errorf("tuple inspected wrong. expected=%q, got=%q", expected, tuple.Inspect())
+				}
+				continue
+			}
+			errObj, ok := evaluated.(*object.Error)
+			if !ok {
+				t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+				continue
+			}
+			if errObj.Message != expected {
+				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+			}
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"5 + true;", "1:1: type mismatch: INTEGER + BOOLEAN"},
+		{"var a = 5;\nvar b = a;\n  -true", "3:3: unknown operator: -BOOLEAN"},
+		{"var f = func() {\n  foo;\n};\nf();", "2:3: identifier not found: foo"},
+		{"var a = 5;\nlen(a);", "2:1: argument to 'len' not supported, got INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.String() != tt.expectedError {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expectedError, errObj.String())
+		}
+	}
+}
+
+func TestRuntimeErrorsDoNotPanic(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"var a = 1;\nvar b = a / 0;", "2:9: division by zero"},
+		{"var a = 1.5;\n  a / 0.0;", "2:3: division by zero"},
+		{"var add = func(a, b) { a + b };\n  add(1);", "2:3: wrong number of arguments: want=2, got=1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.String() != tt.expectedError {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expectedError, errObj.String())
+		}
+	}
+}
+
+func TestEvalRecoversFromPanics(t *testing.T) {
+	evaluator.Builtins["crash"] = &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			panic("something went wrong")
+		},
+	}
+	defer delete(evaluator.Builtins, "crash")
+
+	evaluated := testEval(t, "var a = 1; crash(a);")
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	if errObj.Message != "internal error: something went wrong" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestCallStackAfterPanic(t *testing.T) {
+	evaluator.Builtins["crash"] = &object.Builtin{
+		Fn: func(args ...object.Object) object.Object {
+			panic("something went wrong")
+		},
+	}
+	defer delete(evaluator.Builtins, "crash")
+
+	opts := evaluator.Options{MaxCallDepth: 8}
+	inputs := []string{
+		"func f(n) { if (n == 0) { return crash() } 1 + f(n - 1) }\nf(6)",
+		"func f(n) { if (n == 0) { return 0 } 1 + f(n - 1) }\nf(7)",
+	}
+
+	// the calls that the panic unwound must not count towards the depth of the next program
+	// run by the same evaluator
+	ev := evaluator.New(opts)
+	ev.Eval(parser.New(lexer.New(inputs[0])).ParseProgram(), object.NewEnvironment())
+	testIntegerObject(t, ev.Eval(parser.New(lexer.New(inputs[1])).ParseProgram(), object.NewEnvironment()), 7)
+
+	testEvalWithOptions(t, inputs[0], opts)
+	testIntegerObject(t, testEvalWithOptions(t, inputs[1], opts), 7)
+}
+
+func TestFunctionWithoutValue(t *testing.T) {
+	input := "var f = func() { var a = 1; }; var b = f(); b;"
+
+	testNullObject(t, testEval(t, input))
+}
+
 func TestVarStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -259,14 +624,72 @@ func TestVarStatements(t *testing.T) {
 		{"var a = 5; var b = a; var c = a + b + 5; c;", 15},
 	}
 	for _, tt := range tests {
-		testIntegerObject(t, testEval(tt.input), tt.expected)
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"var a = 5; a = 10; a;", 10},
+		{"var a = 5; a = a + 1;", 6},
+		{"var a = 1; var b = 2; a = b = 3; a + b;", 6},
+		{"var a = 5; a += 2; a;", 7},
+		{"var a = 5; a -= 2; a;", 3},
+		{"var a = 5; a *= 2; a;", 10},
+		{"var a = 6; a /= 2; a;", 3},
+		{"var count = 0; var inc = func() { count += 1; }; inc(); inc(); count;", 2},
+		{"var sum = 0; for (x in [1, 2, 3]) { sum += x; } sum;", 6},
+		{"var sum = 0; for (var i = 0; i < 4; i += 1) { sum += i; } sum;", 6},
+		{"var i = 0; while (i < 5) { i += 1; } i;", 5},
+		{"var arr = [1, 2, 3]; arr[1] = 5; arr[1];", 5},
+		{"var arr = [1, 2, 3]; arr[2] += 5; arr[2];", 8},
+		{"var arr = [1]; var other = arr; other[0] = 2; arr[0];", 2},
+		{`var d = {"a": 1}; d["a"] = 2; d["a"];`, 2},
+		{`var d = {}; d["b"] = 3; d["b"];`, 3},
+		{`var d = {"a": 1}; d["a"] *= 4; d["a"];`, 4},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestAssignErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"x = 5;", "cannot assign to undeclared variable: x"},
+		{"x += 5;", "identifier not found: x"},
+		{"var f = func() { y = 1; }; f();", "cannot assign to undeclared variable: y"},
+		{"var arr = [1]; arr[1] = 2;", "index out of range: 1"},
+		{`var arr = [1]; arr["a"] = 2;`, "array index must be INTEGER, got STRING"},
+		{`var s = "abc"; s[0] = "b";`, "index assignment not supported: STRING"},
+		{`var a = 1; a += true;`, "type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
 	}
 }
 
 func TestFunctionObject(t *testing.T) {
 	input := "func(x) { x + 2; };"
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	fn, ok := evaluated.(*object.Function)
 	if !ok {
 		t.Fatalf("object was not Function. got=%T (%+v)", evaluated, evaluated)
@@ -300,7 +723,160 @@ func TestFunctionApplication(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		testIntegerObject(t, testEval(tt.input), tt.expected)
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestFunctionDeclarations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"func double(x) { x * 2 } double(5);", 10},
+		{"var result = double(5); func double(x) { return x * 2; }; result;", 10},
+		{"func fact(n) { if (n < 2) { return 1; } n * fact(n - 1) } fact(5);", 120},
+		{`
+var result = isEven(10) && isOdd(7);
+
+func isEven(n) { if (n == 0) { return true; } isOdd(n - 1) }
+func isOdd(n) { if (n == 0) { return false; } isEven(n - 1) }
+
+if (result) { 1 } else { 0 }`, 1},
+		{`
+func outer() {
+	return inner() + 1;
+	func inner() { 41 }
+}
+outer();`, 42},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestTailCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"func sum(n, acc) { if (n == 0) { return acc } return sum(n - 1, acc + n) } sum(100000, 0)", 5000050000},
+		{"func count(n) { if (n == 0) { 0 } else { count(n - 1) } } count(100000)", 0},
+		{`
+func isEven(n) { if (n == 0) { return true; } isOdd(n - 1) }
+func isOdd(n) { if (n == 0) { return false; } isEven(n - 1) }
+isEven(100001)`, false},
+		{"func loop(n) { while (true) { if (n == 0) { return n } return loop(n - 1) } } loop(100000)", 0},
+		{"func last(arr) { len(arr) } last([1, 2, 3])", 3},
+		{"func f(n) int { return g(n) } func g(n) int { n * 2 } f(21)", 42},
+		{"func f(n int, acc int) int { if (n == 0) { return acc } f(n - 1, acc + 1) } f(100000, 0)", 100000},
+		{"func f() { var g = func() { 5 }; g() } f()", 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestTailCallErrors(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"func f(n) int { return g(n) }\nfunc g(n) str { n }\nf(1)", "1:24: wrong return type: want=str, got=INTEGER"},
+		{"func f(n) str { return g(n) }\nfunc g(n) { n }\nf(1)", "3:1: wrong return type: want=str, got=INTEGER"},
+		{"func f() { g(1) }\nfunc g() { 1 }\nf()", "1:12: wrong number of arguments: want=0, got=1"},
+		{"func f(n) { if (n == 0) { return n / 0 } f(n - 1) }\nf(3)", "1:34: division by zero"},
+		{"func f() { return 5() }\nf()", "1:19: not a function: INTEGER"},
+		{"func f(n) int { if (n == 0) { return \"s\" } return f(n - 1) }\nf(3)", "1:51: wrong return type: want=int, got=STRING"},
+		{"func f(n) int { if (n == 0) { return g() } return f(n - 1) }\nfunc g() str { 1 }\nf(3)", "1:38: wrong return type: want=str, got=INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.String() != tt.expectedError {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expectedError, errObj.String())
+		}
+	}
+}
+
+func TestMaxCallDepth(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"func f(n) { 1 + f(n + 1) }\nf(0)", "1:17: maximum call depth 8 exceeded\n" +
+			"    at f (1:17)\n    at f (1:17)\n    at f (1:17)\n    at f (1:17)\n    at f (1:17)\n    ... 3 more calls"},
+		{"var g = func() { [g()] }\nvar h = func(n) { if (n == 0) { return g() }; [h(n - 1)] }\nh(3)", "1:19: maximum call depth 8 exceeded\n" +
+			"    at anonymous function (1:19)\n    at anonymous function (1:19)\n    at anonymous function (1:19)\n" +
+			"    at anonymous function (1:19)\n    at anonymous function (2:40)\n    ... 3 more calls"},
+		{"func f(n) { if (n == 0) { return 0 } 1 + f(n - 1) }\nf(8)", "1:42: maximum call depth 8 exceeded\n" +
+			"    at f (1:42)\n    at f (1:42)\n    at f (1:42)\n    at f (1:42)\n    at f (1:42)\n    ... 3 more calls"},
+		{"func f(n) { if (n == 0) { return 0 } 1 + f(n - 1) }\nf(7)", ""},
+		{"func f(n) { if (n == 0) { return 0 } f(n - 1) }\nf(1000)", ""},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWithOptions(t, tt.input, evaluator.Options{MaxCallDepth: 8})
+
+		errObj, ok := evaluated.(*object.Error)
+		if tt.expectedError == "" {
+			if ok {
+				t.Errorf("unexpected error for %q: %s", tt.input, errObj.String())
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.String() != tt.expectedError {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expectedError, errObj.String())
+		}
+	}
+}
+
+func TestDefaultMaxCallDepth(t *testing.T) {
+	evaluated := testEval(t, "func f(n) { if (n == 0) { return 0 } 1 + f(n - 1) }\nf(20000)")
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expected := "1:42: maximum call depth 10000 exceeded\n    at f (1:42)"
+	if !strings.HasPrefix(errObj.String(), expected) {
+		t.Errorf("wrong error. expected prefix %q, got=%q", expected, errObj.String())
+	}
+}
+
+func TestFunctionDeclarationInspect(t *testing.T) {
+	evaluated := testEval(t, "func add(a, b) { a + b } add;")
+	fn, ok := evaluated.(*object.Function)
+	if !ok {
+		t.Fatalf("object was not Function. got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := "func add(a, b) {\n(a + b)\n}"
+	if fn.Inspect() != expected {
+		t.Errorf("function inspected wrong. expected=%q, got=%q", expected, fn.Inspect())
+	}
+
+	if testEval(t, "func(a) { a };").Inspect() != "func(a) {\na\n}" {
+		t.Errorf("anonymous function should not be given a name")
 	}
 }
 
@@ -313,7 +889,70 @@ var newAdder = func(x) {
 var addTwo = newAdder(2);
 addTwo(2);`
 
-	testIntegerObject(t, testEval(input), 4)
+	testIntegerObject(t, testEval(t, input), 4)
+}
+
+func TestResolvedVariables(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"var x = 1; var f = func(x) { x * 10 }; f(2) + x", 21},
+		{"var f = func() { var a = 1; var g = func() { a += 1; a }; g(); g() }; f()", 3},
+		{"var fs = []; for (i in [1, 2, 3]) { fs = push(fs, func() { i }) }; fs[0]()", 3},
+		{"var total = 0; for (var i = 0; i < 4; i += 1) { var double = i * 2; total += double }; total", 12},
+		{"func f() { var g = func() { later }; var later = 5; g() }; f()", 5},
+		{"func f(n) { if (n > 0) { var x = n } else { var x = 0 - n }; x }; f(-3)", 3},
+		{"func fact(n) { if (n < 2) { return 1 }; n * fact(n - 1) }; fact(10)", 3628800},
+		{"var x = 1; var g = func() { var x = x + 1; return x; }; g()", 2},
+		{"var x = 1; var h = func() { var r = x; var x = 5; return r; }; h()", 1},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(t, tt.input), tt.expected)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var f = func() { missing }; 1", "1:18: identifier not found: missing"},
+		{"if (false) { x = 1 }", "1:14: cannot assign to undeclared variable: x"},
+		{"var a = 1; var a = 2;", "1:16: duplicate declaration: a"},
+		{"func(a, a) { a }(1, 2)", "1:9: duplicate declaration: a"},
+		{"var a = 1; var a = 2; a", "1:16: duplicate declaration: a"},
+		{"func g() { var y = 1; var y = 2; y } g()", "1:27: duplicate declaration: y"},
+		{"println(1); undeclared", "1:13: identifier not found: undeclared"},
+		{"var g = func() { var x = x + 1; x }; 1", "1:26: identifier not found: x"},
+		{"var h = func() { var r = y; var y = 5; r }; 1", "1:26: identifier not found: y"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.String() != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected, errObj.String())
+		}
+	}
+}
+
+func TestEnvironmentAcrossPrograms(t *testing.T) {
+	env := object.NewEnvironment()
+
+	inputs := []string{"var count = 1;", "var inc = func() { count += 1 };", "inc(); inc(); count"}
+	var evaluated object.Object
+	for _, input := range inputs {
+		evaluated = evaluator.Eval(parser.New(lexer.New(input)).ParseProgram(), env)
+	}
+
+	testIntegerObject(t, evaluated, 3)
 }
 
 func TestBuiltinFunctions(t *testing.T) {
@@ -324,12 +963,16 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`len("")`, 0},
 		{`len("four")`, 4},
 		{`len("hello world")`, 11},
+		{`len("a\tb")`, 3},
+		{"len(`a\\tb`)", 4},
+		{`len("héllo")`, 5},
+		{`len("日本")`, 2},
 		{`len(1)`, "argument to 'len' not supported, got INTEGER"},
 		{`len("one", "two")`, "wrong number of arguments. got=2, want=1"},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 
 		switch expected := tt.expected.(type) {
 		case int:
@@ -350,7 +993,7 @@ func TestBuiltinFunctions(t *testing.T) {
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	result, ok := evaluated.(*object.Array)
 	if !ok {
 		t.Fatalf("object is not Array. got=%T (%+v)", evaluated, evaluated)
@@ -413,7 +1056,7 @@ func TestArrayIndexExpressions(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		integer, ok := tt.expected.(int)
 		if ok {
 			testIntegerObject(t, evaluated, int64(integer))
@@ -423,6 +1066,107 @@ func TestArrayIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestStringIndexExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"hello"[0]`, 'h'},
+		{`"héllo"[1]`, 'é'},
+		{`"héllo"[2]`, 'l'},
+		{`var s = "日本語"; s[len(s) - 1]`, '語'},
+		{`"abc"[3]`, nil},
+		{`"abc"[-1]`, nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+		expected, ok := tt.expected.(rune)
+		if !ok {
+			testNullObject(t, evaluated)
+			continue
+		}
+
+		testCharObject(t, evaluated, expected)
+	}
+}
+
+func TestStringIteration(t *testing.T) {
+	input := `var out = ""; for (c in "héllo") { out = c + out; } out`
+
+	evaluated := testEval(t, input)
+	str, ok := evaluated.(*object.String)
+	if !ok {
+		t.Fatalf("object is not String. got=%T (%+v)", evaluated, evaluated)
+	}
+	if str.Value != "olléh" {
+		t.Errorf("string has wrong value. expected=%q, got=%q", "olléh", str.Value)
+	}
+}
+
+func TestCharExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`'a'`, 'a'},
+		{`'\n'`, '\n'},
+		{`'é'`, 'é'},
+		{`'a' < 'b'`, true},
+		{`'b' <= 'a'`, false},
+		{`'z' > 'a'`, true},
+		{`'a' >= 'a'`, true},
+		{`'a' == 'a'`, true},
+		{`'a' != 'a'`, false},
+		{`"abc"[1] == 'b'`, true},
+		{`'a' + 'b'`, "ab"},
+		{`'a' + "bc"`, "abc"},
+		{`"ab" + 'c'`, "abc"},
+		{`int('a')`, 97},
+		{`int('語')`, 35486},
+		{`char(98)`, 'b'},
+		{`char("é")`, 'é'},
+		{`str('x')`, "x"},
+		{`str(12)`, "12"},
+		{`int("42")`, 42},
+		{`int(3.9)`, 3},
+		{`{'a': 1, 'b': 2}['b']`, 2},
+		{`'a' - 'b'`, "unknown operator: CHAR - CHAR"},
+		{`char(-1)`, "-1 is not a valid character code point"},
+		{`char("ab")`, "could not convert \"ab\" to CHAR"},
+		{`int("4x")`, "could not convert \"4x\" to INTEGER"},
+		{`int(true)`, "argument to 'int' not supported, got BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(t, tt.input)
+
+		switch expected := tt.expected.(type) {
+		case rune:
+			testCharObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				if errObj.Message != expected {
+					t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
+				}
+				continue
+			}
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("string has wrong value. expected=%q, got=%q", expected, str.Value)
+			}
+		}
+	}
+}
+
 func TestDictionaryLiterals(t *testing.T) {
 	input := `var two = "two";
 	{
@@ -434,7 +1178,7 @@ func TestDictionaryLiterals(t *testing.T) {
 		false: 6
 	}`
 
-	evaluated := testEval(input)
+	evaluated := testEval(t, input)
 	result, ok := evaluated.(*object.Dictionary)
 	if !ok {
 		t.Fatalf("Eval didn't return Dictionary. got=%T (%+v)", evaluated, evaluated)
@@ -445,8 +1189,8 @@ func TestDictionaryLiterals(t *testing.T) {
 		(&object.String{Value: "two"}).DictionaryKey():   2,
 		(&object.String{Value: "three"}).DictionaryKey(): 3,
 		(&object.Integer{Value: 4}).DictionaryKey():      4,
-		TRUE.DictionaryKey():                             5,
-		FALSE.DictionaryKey():                            6,
+		evaluator.TRUE.DictionaryKey():                   5,
+		evaluator.FALSE.DictionaryKey():                  6,
 	}
 
 	if len(result.Pairs) != len(expected) {
@@ -496,10 +1240,22 @@ func TestDictionaryIndexExpressions(t *testing.T) {
 			`{false: 5}[false]`,
 			5,
 		},
+		{
+			`{1.5: 5}[1.5]`,
+			5,
+		},
+		{
+			`{0.0: 5}[-0.0]`,
+			5,
+		},
+		{
+			`{1: 5}[1.0]`,
+			nil,
+		},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
+		evaluated := testEval(t, tt.input)
 		integer, ok := tt.expected.(int)
 		if ok {
 			testIntegerObject(t, evaluated, int64(integer))
@@ -509,13 +1265,152 @@ func TestDictionaryIndexExpressions(t *testing.T) {
 	}
 }
 
-func testEval(input string) object.Object {
+const fibProgram = `
+var fib = func(n) {
+	if (n < 2) { return n; }
+	fib(n - 1) + fib(n - 2)
+};
+fib(20);`
+
+// BenchmarkFib compares finding variables by name in unresolved programs with finding them by
+// their slot once the program is resolved, and with running the program on the virtual machine
+func BenchmarkFib(b *testing.B) {
+	b.Run("unresolved", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			program := parser.New(lexer.New(fibProgram)).ParseProgram()
+			env := object.NewEnvironment()
+			// evaluating the statements one at a time skips the resolver
+			for _, statement := range program.Statements {
+				evaluator.Eval(statement, env)
+			}
+		}
+	})
+
+	b.Run("resolved", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			program := parser.New(lexer.New(fibProgram)).ParseProgram()
+			evaluator.Eval(program, object.NewEnvironment())
+		}
+	})
+
+	b.Run("vm", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			program := parser.New(lexer.New(fibProgram)).ParseProgram()
+			comp := compiler.New()
+			if err := comp.Compile(program); err != nil {
+				b.Fatalf("compiler error: %s", err)
+			}
+			vm.New(comp.Bytecode()).Run()
+		}
+	})
+}
+
+// testEval runs the input with both the evaluator and the bytecode virtual machine, reporting
+// any difference between their results. The evaluator's result is returned to be checked
+func testEval(t *testing.T, input string) object.Object {
+	t.Helper()
+	return testEvalWithOptions(t, input, evaluator.Options{})
+}
+
+// testEvalWithOptions evaluates the input with the options given, checking that the virtual
+// machine gives the same result with the same limits
+func testEvalWithOptions(t *testing.T, input string, opts evaluator.Options) object.Object {
+	t.Helper()
+
 	l := lexer.New(input)
 	p := parser.New(l)
 	program := p.ParseProgram()
 	env := object.NewEnvironment()
 
-	return Eval(program, env)
+	evaluated := evaluator.New(opts).Eval(program, env)
+
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		// the compiler reports the errors found by the resolver before running anything, which
+		// the evaluator must have returned as well
+		if errObj, ok := evaluated.(*object.Error); !ok || errObj.String() != err.Error() {
+			t.Errorf("compiler error differs for %q. evaluator=%s, compiler=%s", input, inspect(evaluated), err)
+		}
+		return evaluated
+	}
+	machine := vm.New(comp.Bytecode())
+	machine.SetMaxCallDepth(opts.MaxCallDepth)
+	result := machine.Run()
+
+	if !sameObject(evaluated, result) {
+		t.Errorf("vm result differs for %q. evaluator=%s, vm=%s", input, inspect(evaluated), inspect(result))
+	}
+
+	return evaluated
+}
+
+// sameObject checks whether two results are the same, comparing errors with their positions
+// and the contents of arrays, tuples and dictionaries
+func sameObject(a object.Object, b object.Object) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	if a.Type() != b.Type() {
+		return false
+	}
+
+	switch a := a.(type) {
+	case *object.Null, *object.Boolean:
+		return a == b
+	case *object.Error:
+		return a.String() == b.(*object.Error).String()
+	case *object.Array:
+		return sameObjects(a.Elements, b.(*object.Array).Elements)
+	case *object.Tuple:
+		return sameObjects(a.Elements, b.(*object.Tuple).Elements)
+	case *object.Dictionary:
+		other := b.(*object.Dictionary)
+		if len(a.Pairs) != len(other.Pairs) {
+			return false
+		}
+		for key, pair := range a.Pairs {
+			otherPair, ok := other.Pairs[key]
+			if !ok || !sameObject(pair.Key, otherPair.Key) || !sameObject(pair.Value, otherPair.Value) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a.Inspect() == b.Inspect()
+	}
+}
+
+func sameObjects(a []object.Object, b []object.Object) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !sameObject(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return "nil"
+	}
+	return obj.Inspect()
+}
+
+func testCharObject(t *testing.T, obj object.Object, expected rune) bool {
+	result, ok := obj.(*object.Char)
+	if !ok {
+		t.Errorf("object is not a char. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. expected %q but got=%q", expected, result.Value)
+		return false
+	}
+
+	return true
 }
 
 func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
@@ -532,8 +1427,22 @@ func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	return true
 }
 
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is not a float. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. expected %g but got=%g", expected, result.Value)
+		return false
+	}
+
+	return true
+}
+
 func testNullObject(t *testing.T, obj object.Object) bool {
-	if obj != NULL {
+	if obj != evaluator.NULL {
 		t.Errorf("object is not NULL. got=%T", obj)
 		return false
 	}
@@ -7,9 +7,11 @@ func NewEnvironment() *Environment {
 }
 
 // Environment is the environment of the program. It is responsible for storing
-// and binding identifers to their object values
+// and binding identifers to their object values. Variables that the resolver has
+// found a slot for are kept in the slots array, and any others are kept by name
 type Environment struct {
 	store map[string]Object
+	slots []Object
 	outer *Environment
 }
 
@@ -25,13 +27,89 @@ func (e *Environment) Get(name string) (Object, bool) {
 // Set creates a new binding in the environment between the string and the
 // object that have been sent in
 func (e *Environment) Set(name string, value Object) Object {
+	if e.store == nil {
+		e.store = make(map[string]Object)
+	}
 	e.store[name] = value
 	return value
 }
 
+// Assign updates an existing binding of the string sent in, looking through the outer
+// environments if the string isn't bound in this one. It returns false if the string
+// isn't bound in any environment
+func (e *Environment) Assign(name string, value Object) (Object, bool) {
+	if _, ok := e.store[name]; ok {
+		e.store[name] = value
+		return value, true
+	}
+	if e.outer != nil {
+		return e.outer.Assign(name, value)
+	}
+	return nil, false
+}
+
+// Names returns the names of the variables that are bound by name in the environment and
+// the environments around it
+func (e *Environment) Names() []string {
+	var names []string
+	for env := e; env != nil; env = env.outer {
+		for name := range env.store {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Ancestor returns the environment that is the given number of environments out from this one
+func (e *Environment) Ancestor(depth int) *Environment {
+	env := e
+	for i := 0; i < depth; i++ {
+		env = env.outer
+	}
+	return env
+}
+
+// GetAt returns the object in a slot of the environment that is the given number of
+// environments out from this one. It returns false if nothing has been stored in the slot yet
+func (e *Environment) GetAt(depth, slot int) (Object, bool) {
+	env := e.Ancestor(depth)
+	if slot >= len(env.slots) || env.slots[slot] == nil {
+		return nil, false
+	}
+	return env.slots[slot], true
+}
+
+// SetAt stores an object in a slot of the environment
+func (e *Environment) SetAt(slot int, value Object) Object {
+	if slot >= len(e.slots) {
+		slots := make([]Object, slot+1)
+		copy(slots, e.slots)
+		e.slots = slots
+	}
+	e.slots[slot] = value
+	return value
+}
+
+// AssignAt updates the object in a slot of the environment that is the given number of
+// environments out from this one. It returns false if nothing has been stored in the slot yet
+func (e *Environment) AssignAt(depth, slot int, value Object) (Object, bool) {
+	env := e.Ancestor(depth)
+	if slot >= len(env.slots) || env.slots[slot] == nil {
+		return nil, false
+	}
+	env.slots[slot] = value
+	return value, true
+}
+
 // NewEnclosedEnvironment creates a nested environment for function executions
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
 	env.outer = outer
 	return env
 }
+
+// NewSlotEnvironment creates a nested environment with room for the number of variables that
+// the resolver has given slots to. The variable store is only made if a variable is bound by name
+func NewSlotEnvironment(outer *Environment, slots int) *Environment {
+	return &Environment{slots: make([]Object, slots), outer: outer}
+}
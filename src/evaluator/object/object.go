@@ -4,8 +4,13 @@ import (
 	"bytes"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/kai119/Brisk/src/compiler/code"
+	"github.com/kai119/Brisk/src/lexer/token"
 	"github.com/kai119/Brisk/src/parser/ast"
 )
 
@@ -15,15 +20,23 @@ type Type string
 // Object Type representations
 const (
 	INTEGER_OBJ      = "INTEGER"
+	FLOAT_OBJ        = "FLOAT"
 	STRING_OBJ       = "STRING"
+	CHAR_OBJ         = "CHAR"
 	BOOLEAN_OBJ      = "BOOLEAN"
 	NULL_OBJ         = "NULL"
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
+	TAIL_CALL_OBJ    = "TAIL_CALL"
+	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
 	ERROR_OBJ        = "ERROR"
 	FUNCTION_OBJ     = "FUNCTION"
 	BUILTIN_OBJ      = "BUILTIN"
 	ARRAY_OBJ        = "ARRAY"
 	DICTIONARY_OBJ   = "DICTIONARY"
+	TUPLE_OBJ        = "TUPLE"
+
+	COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
 )
 
 // Object is an evaluated object that contains the type of the object
@@ -60,6 +73,34 @@ func (i *Integer) DictionaryKey() DictionaryKey {
 	return DictionaryKey{Type: i.Type(), Value: uint64(i.Value)}
 }
 
+// Float represents a floating-point number in BRISK
+type Float struct {
+	Value float64
+}
+
+// Inspect returns the string representation of the object. Whole numbers are
+// always shown with a decimal point so that they can't be mistaken for integers
+func (f *Float) Inspect() string {
+	str := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(str, ".eIN") {
+		str += ".0"
+	}
+	return str
+}
+
+// Type returns the type of the object
+func (f *Float) Type() Type { return FLOAT_OBJ }
+
+// DictionaryKey the dictionary key of the object
+func (f *Float) DictionaryKey() DictionaryKey {
+	value := f.Value
+	if value == 0 {
+		// -0.0 and 0.0 are equal so they must share a key
+		value = 0
+	}
+	return DictionaryKey{Type: f.Type(), Value: math.Float64bits(value)}
+}
+
 // String represents a string in BRISK
 type String struct {
 	Value string
@@ -81,6 +122,22 @@ func (s *String) DictionaryKey() DictionaryKey {
 	return DictionaryKey{Type: s.Type(), Value: h.Sum64()}
 }
 
+// Char represents a single character in BRISK
+type Char struct {
+	Value rune
+}
+
+// Inspect returns the string representation of the object
+func (c *Char) Inspect() string { return string(c.Value) }
+
+// Type returns the type of the object
+func (c *Char) Type() Type { return CHAR_OBJ }
+
+// DictionaryKey the dictionary key of the object
+func (c *Char) DictionaryKey() DictionaryKey {
+	return DictionaryKey{Type: c.Type(), Value: uint64(c.Value)}
+}
+
 // Boolean represents a boolean in BRISK
 type Boolean struct {
 	Value bool
@@ -124,22 +181,73 @@ func (rv *ReturnValue) Inspect() string { return rv.Value.Inspect() }
 // Type returns the type of the object
 func (rv *ReturnValue) Type() Type { return RETURN_VALUE_OBJ }
 
-// Error represents an error in BRISK
+// TailCall represents a call in tail position that hasn't been made yet. It is passed up
+// through the blocks of a function until it reaches the function call, which then makes
+// the call in its place so that the Go stack doesn't grow
+type TailCall struct {
+	Function  Object
+	Arguments []Object
+	Pos       token.Position
+}
+
+// Inspect returns the string representation of the object
+func (tc *TailCall) Inspect() string { return "tail call" }
+
+// Type returns the type of the object
+func (tc *TailCall) Type() Type { return TAIL_CALL_OBJ }
+
+// Break represents a break statement in BRISK. It is passed up through the blocks
+// of a loop until it reaches the loop, which then stops
+type Break struct{}
+
+// Inspect returns the string representation of the object
+func (b *Break) Inspect() string { return "break" }
+
+// Type returns the type of the object
+func (b *Break) Type() Type { return BREAK_OBJ }
+
+// Continue represents a continue statement in BRISK. It is passed up through the
+// blocks of a loop until it reaches the loop, which then starts its next iteration
+type Continue struct{}
+
+// Inspect returns the string representation of the object
+func (c *Continue) Inspect() string { return "continue" }
+
+// Type returns the type of the object
+func (c *Continue) Type() Type { return CONTINUE_OBJ }
+
+// Error represents an error in BRISK along with the position in the source code
+// that the error occurred at
 type Error struct {
 	Message string
+	Pos     token.Position
 }
 
 // Inspect returns the string representation of the object
-func (e *Error) Inspect() string { return "ERROR: " + e.Message }
+func (e *Error) Inspect() string { return "ERROR: " + e.String() }
+
+// String returns the error message prefixed with its position if it has one,
+// for example script.brisk:12:7: type mismatch: INTEGER + BOOLEAN
+func (e *Error) String() string {
+	if e.Pos.IsValid() {
+		return e.Pos.String() + ": " + e.Message
+	}
+	return e.Message
+}
 
 // Type returns the type of the object
 func (e *Error) Type() Type { return ERROR_OBJ }
 
 // Function represents a function in BRISK
 type Function struct {
-	Parameters []*ast.Identifier
-	Body       *ast.BlockStatement
-	Env        *Environment
+	Name           string
+	Parameters     []*ast.Identifier
+	ParameterTypes []*ast.Type
+	ReturnType     *ast.Type
+	Body           *ast.BlockStatement
+	Env            *Environment
+	// Slots is the number of variables that each call of the function stores by slot
+	Slots int
 }
 
 // Inspect returns the string representation of the object
@@ -147,7 +255,11 @@ func (f *Function) Inspect() string {
 	var out bytes.Buffer
 
 	params := []string{}
-	for _, p := range f.Parameters {
+	for i, p := range f.Parameters {
+		if i < len(f.ParameterTypes) && f.ParameterTypes[i] != nil {
+			params = append(params, p.String()+" "+f.ParameterTypes[i].String())
+			continue
+		}
 		params = append(params, p.String())
 	}
 
@@ -155,6 +267,12 @@ func (f *Function) Inspect() string {
 	if err != nil {
 		return ""
 	}
+	if f.Name != "" {
+		_, err = out.WriteString(" " + f.Name)
+		if err != nil {
+			return ""
+		}
+	}
 	_, err = out.WriteString("(")
 	if err != nil {
 		return ""
@@ -163,7 +281,17 @@ func (f *Function) Inspect() string {
 	if err != nil {
 		return ""
 	}
-	_, err = out.WriteString(") {\n")
+	_, err = out.WriteString(")")
+	if err != nil {
+		return ""
+	}
+	if f.ReturnType != nil {
+		_, err = out.WriteString(" " + f.ReturnType.String())
+		if err != nil {
+			return ""
+		}
+	}
+	_, err = out.WriteString(" {\n")
 	if err != nil {
 		return ""
 	}
@@ -182,6 +310,57 @@ func (f *Function) Inspect() string {
 // Type returns the type of the object
 func (f *Function) Type() Type { return FUNCTION_OBJ }
 
+// CompiledFunction is a function that has been compiled to bytecode, which is kept in the
+// constant pool of a program until a closure is made from it
+type CompiledFunction struct {
+	Instructions code.Instructions
+	Positions    code.Positions
+	// NumParameters is the number of parameters, which take up the first local variables
+	NumParameters int
+	// LocalNames and FreeNames are the names of the local variables of the function and of
+	// the variables that it captures, which are used in error messages
+	LocalNames []string
+	FreeNames  []string
+	// Literal is the function that was compiled, which is nil for the main program
+	Literal *ast.FunctionLiteral
+}
+
+// Inspect returns the string representation of the object
+func (cf *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+
+// Type returns the type of the object
+func (cf *CompiledFunction) Type() Type { return COMPILED_FUNCTION_OBJ }
+
+// Closure is a compiled function along with the variables that it has captured from the
+// functions around it. It is the bytecode virtual machine's version of a Function
+type Closure struct {
+	Fn   *CompiledFunction
+	Free []Object
+}
+
+// Inspect returns the string representation of the object, which is the same as that of the
+// Function the evaluator would have made
+func (c *Closure) Inspect() string {
+	lit := c.Fn.Literal
+	if lit == nil {
+		return "func() {\n\n}"
+	}
+
+	fn := &Function{
+		Name:           lit.Name,
+		Parameters:     lit.Parameters,
+		ParameterTypes: lit.ParameterTypes,
+		ReturnType:     lit.ReturnType,
+		Body:           lit.Body,
+	}
+	return fn.Inspect()
+}
+
+// Type returns the type of the object
+func (c *Closure) Type() Type { return FUNCTION_OBJ }
+
 // BuiltinFunction represents an instance of a builtin function
 type BuiltinFunction func(args ...Object) Object
 
@@ -229,6 +408,24 @@ func (a *Array) Inspect() string {
 // Type returns the type of the object
 func (a *Array) Type() Type { return ARRAY_OBJ }
 
+// Tuple represents several values that were returned together from a function
+type Tuple struct {
+	Elements []Object
+}
+
+// Inspect returns the string representation of the object
+func (t *Tuple) Inspect() string {
+	elements := []string{}
+	for _, e := range t.Elements {
+		elements = append(elements, e.Inspect())
+	}
+
+	return "(" + strings.Join(elements, ", ") + ")"
+}
+
+// Type returns the type of the object
+func (t *Tuple) Type() Type { return TUPLE_OBJ }
+
 // DictionaryPair represents a key value pair in a dictionary
 type DictionaryPair struct {
 	Key   Object
@@ -243,12 +440,46 @@ type Dictionary struct {
 // Type returns the type of the object
 func (d *Dictionary) Type() Type { return DICTIONARY_OBJ }
 
+// SortedPairs returns the pairs of the dictionary sorted by their keys, so that a dictionary is
+// always iterated over in the same order. Keys of different types are ordered by their type
+func (d *Dictionary) SortedPairs() []DictionaryPair {
+	pairs := make([]DictionaryPair, 0, len(d.Pairs))
+	for _, pair := range d.Pairs {
+		pairs = append(pairs, pair)
+	}
+
+	sort.Slice(pairs, func(i, j int) bool { return keyLess(pairs[i].Key, pairs[j].Key) })
+	return pairs
+}
+
+// keyLess reports whether one dictionary key is ordered before another
+func keyLess(a, b Object) bool {
+	if a.Type() != b.Type() {
+		return a.Type() < b.Type()
+	}
+
+	switch a := a.(type) {
+	case *Integer:
+		return a.Value < b.(*Integer).Value
+	case *Float:
+		return a.Value < b.(*Float).Value
+	case *String:
+		return a.Value < b.(*String).Value
+	case *Char:
+		return a.Value < b.(*Char).Value
+	case *Boolean:
+		return !a.Value && b.(*Boolean).Value
+	default:
+		return false
+	}
+}
+
 // Inspect returns the string representation of the object
 func (d *Dictionary) Inspect() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for _, pair := range d.Pairs {
+	for _, pair := range d.SortedPairs() {
 		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
 	}
 
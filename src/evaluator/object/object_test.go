@@ -20,3 +20,61 @@ func TestStringDictionaryKey(t *testing.T) {
 		t.Errorf("strings with different content have the same dictionary keys")
 	}
 }
+
+func TestFloatDictionaryKey(t *testing.T) {
+	one1 := &Float{Value: 1.5}
+	one2 := &Float{Value: 1.5}
+	diff := &Float{Value: 2.5}
+
+	if one1.DictionaryKey() != one2.DictionaryKey() {
+		t.Errorf("floats with same value have different dictionary keys")
+	}
+
+	if one1.DictionaryKey() == diff.DictionaryKey() {
+		t.Errorf("floats with different values have the same dictionary keys")
+	}
+
+	if (&Float{Value: 1}).DictionaryKey() == (&Integer{Value: 1}).DictionaryKey() {
+		t.Errorf("float and integer have the same dictionary keys")
+	}
+}
+
+func TestCharDictionaryKey(t *testing.T) {
+	a1 := &Char{Value: 'a'}
+	a2 := &Char{Value: 'a'}
+	diff := &Char{Value: 'b'}
+
+	if a1.DictionaryKey() != a2.DictionaryKey() {
+		t.Errorf("chars with same value have different dictionary keys")
+	}
+
+	if a1.DictionaryKey() == diff.DictionaryKey() {
+		t.Errorf("chars with different values have the same dictionary keys")
+	}
+
+	if a1.DictionaryKey() == (&Integer{Value: 'a'}).DictionaryKey() {
+		t.Errorf("char and integer have the same dictionary keys")
+	}
+}
+
+func TestDictionarySortedPairs(t *testing.T) {
+	keys := []Object{
+		&String{Value: "b"}, &Integer{Value: 10}, &String{Value: "a"}, &Integer{Value: -2},
+		&Boolean{Value: true}, &Boolean{Value: false}, &Char{Value: 'z'}, &Float{Value: 0.5},
+	}
+	dict := &Dictionary{Pairs: make(map[DictionaryKey]DictionaryPair)}
+	for _, key := range keys {
+		dict.Pairs[key.(Hashable).DictionaryKey()] = DictionaryPair{Key: key, Value: key}
+	}
+
+	expected := []string{"false", "true", "z", "0.5", "-2", "10", "a", "b"}
+	pairs := dict.SortedPairs()
+	if len(pairs) != len(expected) {
+		t.Fatalf("wrong number of pairs. expected=%d, got=%d", len(expected), len(pairs))
+	}
+	for i, pair := range pairs {
+		if pair.Key.Inspect() != expected[i] {
+			t.Errorf("wrong key at %d. expected=%q, got=%q", i, expected[i], pair.Key.Inspect())
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/kai119/Brisk/src/evaluator/object"
+	"github.com/kai119/Brisk/src/lexer/token"
+	"github.com/kai119/Brisk/src/parser/ast"
+	"github.com/kai119/Brisk/src/resolver"
+)
+
+// The operations below are shared with the bytecode virtual machine, so that a program gives
+// the same results and the same errors whichever engine runs it
+
+// Resolve fills in the bindings of the identifiers in a program and marks its tail calls
+// before it is run by either engine, returning the first error found by the resolver. The
+// global variables given and the builtin functions can be used by the program
+func Resolve(program *ast.Program, globals []string) *resolver.Error {
+	for name := range Builtins {
+		globals = append(globals, name)
+	}
+
+	errors := resolver.Resolve(program, globals)
+	if len(errors) != 0 {
+		return errors[0]
+	}
+
+	markTailCalls(program)
+	return nil
+}
+
+// callStackFrames is the number of the most recent calls that are listed when the maximum
+// call depth is exceeded
+const callStackFrames = 5
+
+// CallFrame is a call of a BRISK function that is still running, made from the position given
+type CallFrame struct {
+	Name string
+	Pos  token.Position
+}
+
+// CallDepthError reports that the maximum call depth has been exceeded, listing the most
+// recent calls on the call stack, which holds the calls that are running with the most
+// recent last
+func CallDepthError(maxCallDepth int, callStack []CallFrame) *object.Error {
+	var out strings.Builder
+	fmt.Fprintf(&out, "maximum call depth %d exceeded", maxCallDepth)
+
+	for i := len(callStack) - 1; i >= 0 && i >= len(callStack)-callStackFrames; i-- {
+		name := callStack[i].Name
+		if name == "" {
+			name = "anonymous function"
+		}
+		fmt.Fprintf(&out, "\n    at %s (%s)", name, callStack[i].Pos)
+	}
+	if len(callStack) > callStackFrames {
+		fmt.Fprintf(&out, "\n    ... %d more calls", len(callStack)-callStackFrames)
+	}
+
+	return &object.Error{Message: out.String()}
+}
+
+// InfixOperation applies an infix operator, other than the logical operators, to two values
+func InfixOperation(operator string, left object.Object, right object.Object) object.Object {
+	return evalInfixExpression(operator, left, right)
+}
+
+// PrefixOperation applies a prefix operator to a value
+func PrefixOperation(operator string, right object.Object) object.Object {
+	return evalPrefixExpression(operator, right)
+}
+
+// IndexOperation gets the value at an index of an array, string or dictionary
+func IndexOperation(left object.Object, index object.Object) object.Object {
+	return evalIndexExpression(left, index)
+}
+
+// IndexAssignment sets the value at an index of an array or dictionary, returning the value
+func IndexAssignment(left object.Object, index object.Object, value object.Object) object.Object {
+	return evalIndexAssignment(left, index, value)
+}
+
+// IsTruthy checks whether a value counts as true in a condition. Only false and null don't
+func IsTruthy(obj object.Object) bool {
+	return isTruthy(obj)
+}
+
+// IsOfType checks whether a value holds the type with the specified name, such as int or str
+func IsOfType(obj object.Object, name string) bool {
+	return obj.Type() == typeObjects[name]
+}
+
+// Elements gets the values that a for-in loop binds to its variable, which are the elements
+// of an array, the characters of a string or the keys of a dictionary in sorted order
+func Elements(iterable object.Object) ([]object.Object, *object.Error) {
+	var elements []object.Object
+
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		elements = iterable.Elements
+	case *object.String:
+		for _, ch := range iterable.Value {
+			elements = append(elements, &object.Char{Value: ch})
+		}
+	case *object.Dictionary:
+		for _, pair := range iterable.SortedPairs() {
+			elements = append(elements, pair.Key)
+		}
+	default:
+		return nil, newError("cannot iterate over %s", iterable.Type())
+	}
+
+	return elements, nil
+}
+
+// Unpack gets the values of the variables in a destructuring declaration. Tuples and arrays
+// must have exactly one value for each variable, while dictionaries must have a string key
+// matching the name of each variable
+func Unpack(kind ast.Destructure, names []string, val object.Object) ([]object.Object, *object.Error) {
+	var values []object.Object
+
+	switch kind {
+	case ast.TupleDestructure:
+		tuple, ok := val.(*object.Tuple)
+		if !ok {
+			return nil, newError("wrong number of values to unpack: want=%d, got=1", len(names))
+		}
+		values = tuple.Elements
+	case ast.ArrayDestructure:
+		array, ok := val.(*object.Array)
+		if !ok {
+			return nil, newError("cannot destructure %s as an array", val.Type())
+		}
+		values = array.Elements
+	case ast.DictionaryDestructure:
+		dict, ok := val.(*object.Dictionary)
+		if !ok {
+			return nil, newError("cannot destructure %s as a dictionary", val.Type())
+		}
+		for _, name := range names {
+			key := &object.String{Value: name}
+			pair, ok := dict.Pairs[key.DictionaryKey()]
+			if !ok {
+				return nil, newError("key not found in dictionary: %s", name)
+			}
+			values = append(values, pair.Value)
+		}
+	}
+
+	if len(values) != len(names) {
+		return nil, newError("wrong number of values to unpack: want=%d, got=%d", len(names), len(values))
+	}
+
+	return values, nil
+}
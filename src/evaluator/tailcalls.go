@@ -0,0 +1,61 @@
+package evaluator
+
+import "github.com/kai119/Brisk/src/parser/ast"
+
+// markTailCalls marks the calls in a program that are in tail position, which are the calls
+// that are returned by a return statement and the calls that a function body ends in. A tail
+// call is made by the function call that it returns from, instead of inside it, so that
+// recursion through tail calls doesn't grow the Go stack
+func markTailCalls(program *ast.Program) {
+	ast.Inspect(program, func(node ast.Node) bool {
+		if fl, ok := node.(*ast.FunctionLiteral); ok {
+			markFunction(fl)
+			return false
+		}
+		return true
+	})
+}
+
+// markFunction marks the tail calls of a function literal and of the functions declared in it
+func markFunction(fl *ast.FunctionLiteral) {
+	if fl.Body == nil {
+		return
+	}
+
+	markLastCall(fl.Body)
+
+	ast.Inspect(fl.Body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.FunctionLiteral:
+			markFunction(node)
+			return false
+		case *ast.ReturnStatement:
+			if call, ok := node.ReturnValue.(*ast.CallExpression); ok {
+				call.Tail = true
+			}
+		}
+		return true
+	})
+}
+
+// markLastCall marks the call that a block ends in, whose value is the value of the block.
+// If the block ends in an if expression, the value of the block is the value of whichever
+// branch is taken, so the branches are marked as well
+func markLastCall(block *ast.BlockStatement) {
+	if block == nil || len(block.Statements) == 0 {
+		return
+	}
+
+	statement, ok := block.Statements[len(block.Statements)-1].(*ast.ExpressionStatement)
+	if !ok {
+		return
+	}
+
+	switch exp := statement.Expression.(type) {
+	case *ast.CallExpression:
+		exp.Tail = true
+	case *ast.IfExpression:
+		markLastCall(exp.Consequence)
+		markLastCall(exp.Alternative)
+	}
+}
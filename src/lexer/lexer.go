@@ -1,38 +1,91 @@
 package lexer
 
-import "github.com/kai119/Brisk/src/lexer/token"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kai119/Brisk/src/lexer/token"
+)
 
 // Lexer is a struct that contains information on the string that was inputted,
 // the position of the pointer in the string, the read position of the next character in the string
-// and the character that the pointer is pointing to
+// and the character that the pointer is pointing to. Characters are read as UTF-8 encoded runes,
+// so positions are byte offsets while columns count characters. It also keeps track of the file name, line
+// and column of the pointer so that every token can be given its position in the source code
 type Lexer struct {
 	input        string
+	filename     string
 	position     int
 	readPosition int
-	ch           byte
+	line         int
+	column       int
+	ch           rune
+
+	keepComments bool
+	comments     []token.Token
 }
 
 // New creates a new Lexer with the input that was parsed, the position at 0, the read position
 // at 1 and the character equal to the first character in the string
 func New(input string) *Lexer {
+	return NewFile("", input)
+}
+
+// NewFile creates a new Lexer in the same way as New, but records the name of the file that
+// the input was read from in the position of every token
+func NewFile(filename string, input string) *Lexer {
 	l := &Lexer{
-		input: input,
+		input:    input,
+		filename: filename,
+		line:     1,
 	}
 	l.readChar()
 
 	return l
 }
 
-//TODO support Unicode with runes at some point
+// KeepComments makes the lexer record every comment it skips over so that they can be
+// retrieved with Comments. Comments are never returned by NextToken, so tools such as a
+// formatter can use them without the parser having to know about them. This must be
+// called before any tokens are read
+func (l *Lexer) KeepComments() {
+	l.keepComments = true
+}
+
+// Comments returns the comments that have been read so far as COMMENT tokens, with
+// literals that include the comment delimiters. This is always empty unless KeepComments
+// has been called
+func (l *Lexer) Comments() []token.Token {
+	return l.comments
+}
 
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+	l.position = l.readPosition
 	if l.readPosition >= len(l.input) {
 		l.ch = 0
+		l.readPosition++
 	} else {
-		l.ch = l.input[l.readPosition]
+		ch, width := utf8.DecodeRuneInString(l.input[l.readPosition:])
+		l.ch = ch
+		l.readPosition += width
+	}
+	l.column++
+}
+
+func (l *Lexer) currentPosition() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Line:     l.line,
+		Column:   l.column,
+		Offset:   l.position,
 	}
-	l.position = l.readPosition
-	l.readPosition++
 }
 
 // NextToken will check the character at the current pointer position to see if it matches any
@@ -44,6 +97,13 @@ func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
+	for l.ch == '/' && (l.peekChar() == '/' || l.peekChar() == '*') {
+		if illegal, ok := l.skipComment(); !ok {
+			return illegal
+		}
+		l.skipWhitespace()
+	}
+	pos := l.currentPosition()
 
 	switch l.ch {
 	case 0:
@@ -77,17 +137,17 @@ func (l *Lexer) NextToken() token.Token {
 	case ']':
 		tok = newToken(token.RIGHT_SQUARE_BRACKET, l.ch)
 	case '\'':
-		tok = newToken(token.CHAR_QUOAT, l.ch)
+		tok = newCharToken(l.readCharLiteral())
 	case '-':
-		tok = newToken(token.MINUS, l.ch)
+		tok = l.newOperatorToken(token.MINUS, token.MINUS_EQUALS)
 	case '+':
-		tok = newToken(token.PLUS, l.ch)
+		tok = l.newOperatorToken(token.PLUS, token.PLUS_EQUALS)
 	case '/':
-		tok = newToken(token.DIVIDE, l.ch)
+		tok = l.newOperatorToken(token.DIVIDE, token.DIVIDE_EQUALS)
 	case '*':
-		tok = newToken(token.MULTIPLY, l.ch)
+		tok = l.newOperatorToken(token.MULTIPLY, token.MULTIPLY_EQUALS)
 	case '%':
-		tok = newToken(token.MOD, l.ch)
+		tok = l.newOperatorToken(token.MOD, token.MOD_EQUALS)
 	case '<':
 		if l.peekChar() == '=' {
 			ch := l.ch
@@ -115,22 +175,29 @@ func (l *Lexer) NextToken() token.Token {
 		} else {
 			tok = newToken(token.CONDITION_NOT, l.ch)
 		}
+	case '&':
+		tok = l.newDoubleCharToken(token.LOGICAL_AND)
+	case '|':
+		tok = l.newDoubleCharToken(token.LOGICAL_OR)
 	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		tok = newStringToken(l.readString())
+	case '`':
+		tok = newStringToken(l.readRawString())
 	default:
 		if isLetter(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
+			tok.Pos = pos
 			return tok
 		} else if isInteger(l.ch) {
-			tok.Type = token.INT
-			tok.Literal = l.readNumber()
+			tok.Type, tok.Literal = l.readNumber()
+			tok.Pos = pos
 			return tok
 		}
 		tok = newToken(token.ILLEGAL, l.ch)
 	}
 	l.readChar()
+	tok.Pos = pos
 	return tok
 }
 
@@ -142,23 +209,235 @@ func (l *Lexer) readIdentifier() string {
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readNumber() string {
+// readNumber reads an integer or a floating-point number. A number becomes a float if it
+// has a decimal point followed by a digit (3.14) or an exponent (1e-9, 2.5E+3). Integers can
+// also be written in hexadecimal (0x1F), octal (0o17) or binary (0b1010), and digits can be
+// separated by underscores (1_000_000). If the number is malformed, an illegal token type is
+// returned along with a message explaining why
+func (l *Lexer) readNumber() (token.Type, string) {
 	position := l.position
-	for isInteger(l.ch) {
+	var tokenType token.Type = token.INT
+
+	if l.ch == '0' {
+		if name, isDigit := numberBase(l.peekChar()); isDigit != nil {
+			l.readChar()
+			l.readChar()
+			return l.readPrefixedInteger(position, name, isDigit)
+		}
+	}
+
+	l.readDigits()
+
+	if l.ch == '.' && isInteger(l.peekChar()) {
+		tokenType = token.FLOAT
 		l.readChar()
+		l.readDigits()
 	}
-	return l.input[position:l.position]
+
+	if (l.ch == 'e' || l.ch == 'E') && l.isExponent() {
+		tokenType = token.FLOAT
+		l.readChar()
+		if l.ch == '+' || l.ch == '-' {
+			l.readChar()
+		}
+		l.readDigits()
+	}
+
+	literal := l.input[position:l.position]
+	if !hasValidSeparators(literal, isInteger) {
+		return token.ILLEGAL, fmt.Sprintf("'_' must separate successive digits: %s", literal)
+	}
+
+	// a leading zero would make the literal octal in some languages, so it isn't allowed
+	// to avoid any confusion over what value the literal has
+	if tokenType == token.INT && literal[0] == '0' && len(literal) > 1 {
+		return token.ILLEGAL, fmt.Sprintf("malformed decimal literal: leading zero in %s", literal)
+	}
+
+	return tokenType, literal
+}
+
+// readPrefixedInteger reads the digits of an integer after its base prefix. Any letters or digits
+// straight after the prefix are read as part of the number so that literals such as 0xZZ are
+// reported as malformed rather than being split into several tokens
+func (l *Lexer) readPrefixedInteger(position int, name string, isDigit func(rune) bool) (token.Type, string) {
+	for isLetter(l.ch) || isInteger(l.ch) {
+		l.readChar()
+	}
+
+	literal := l.input[position:l.position]
+	digits := literal[2:]
+	if strings.Trim(digits, "_") == "" {
+		return token.ILLEGAL, fmt.Sprintf("malformed %s literal: %s has no digits", name, literal)
+	}
+
+	for _, ch := range digits {
+		if ch != '_' && !isDigit(ch) {
+			return token.ILLEGAL, fmt.Sprintf("malformed %s literal: invalid digit %q in %s", name, ch, literal)
+		}
+	}
+
+	if !hasValidSeparators(digits, isDigit) {
+		return token.ILLEGAL, fmt.Sprintf("'_' must separate successive digits: %s", literal)
+	}
+
+	return token.INT, literal
+}
+
+func (l *Lexer) readDigits() {
+	for isInteger(l.ch) || l.ch == '_' {
+		l.readChar()
+	}
+}
+
+// isExponent checks whether the 'e' at the pointer is followed by the digits of an exponent,
+// so that an identifier straight after a number is not read as part of it
+func (l *Lexer) isExponent() bool {
+	next := l.peekChar()
+	if next == '+' || next == '-' {
+		next = l.peekCharAt(2)
+	}
+	return isInteger(next)
+}
+
+// readString reads a double quoted string, replacing any escape sequences with the characters
+// they represent. If the string can't be read, a message explaining why is returned instead
+func (l *Lexer) readString() (string, string) {
+	var out strings.Builder
+	var msg string
+
+	for {
+		l.readChar()
+
+		switch l.ch {
+		case '"':
+			return out.String(), msg
+		case 0:
+			return "", "unterminated string"
+		case '\\':
+			l.readChar()
+			// carry on to the end of the string after a bad escape so that the rest
+			// of the input is still read correctly
+			if escapeMsg := l.readEscape(&out); escapeMsg != "" && msg == "" {
+				msg = escapeMsg
+			}
+		default:
+			out.WriteRune(l.ch)
+		}
+	}
+}
+
+// readEscape writes the character represented by the escape sequence at the pointer,
+// which is just after the backslash. Unicode escapes are in the form \u{1F600}
+func (l *Lexer) readEscape(out *strings.Builder) string {
+	switch l.ch {
+	case 'n':
+		out.WriteByte('\n')
+	case 't':
+		out.WriteByte('\t')
+	case 'r':
+		out.WriteByte('\r')
+	case '\\':
+		out.WriteByte('\\')
+	case '"':
+		out.WriteByte('"')
+	case '\'':
+		out.WriteByte('\'')
+	case 'u':
+		return l.readUnicodeEscape(out)
+	case 0:
+		return "unterminated string"
+	default:
+		return fmt.Sprintf("unknown escape sequence: \\%c", l.ch)
+	}
+	return ""
 }
 
-func (l *Lexer) readString() string {
+func (l *Lexer) readUnicodeEscape(out *strings.Builder) string {
+	if l.peekChar() != '{' {
+		return "invalid unicode escape: expected { after \\u"
+	}
+	l.readChar()
+
 	position := l.position + 1
+	for isHexDigit(l.peekChar()) {
+		l.readChar()
+	}
+	digits := l.input[position : l.position+1]
+
+	if l.peekChar() != '}' {
+		return fmt.Sprintf("invalid unicode escape: \\u{%s", digits)
+	}
+	l.readChar()
+
+	value, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || len(digits) > 6 || !utf8.ValidRune(rune(value)) {
+		return fmt.Sprintf("invalid unicode escape: \\u{%s}", digits)
+	}
+
+	out.WriteRune(rune(value))
+	return ""
+}
+
+// readCharLiteral reads a single quoted character, which can use the same escape sequences
+// as strings. If the literal doesn't contain exactly one character, a message explaining
+// why is returned instead
+func (l *Lexer) readCharLiteral() (string, string) {
+	var out strings.Builder
+	var msg string
+
 	for {
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
-			break
+
+		switch l.ch {
+		case '\'':
+			if msg != "" {
+				return "", msg
+			}
+			if out.Len() == 0 {
+				return "", "empty character literal"
+			}
+			if utf8.RuneCountInString(out.String()) != 1 {
+				return "", "character literal must contain exactly one character"
+			}
+			return out.String(), ""
+		case 0, '\n':
+			return "", "unterminated character literal"
+		case '\\':
+			if l.peekChar() == 0 {
+				return "", "unterminated character literal"
+			}
+			l.readChar()
+			if escapeMsg := l.readEscape(&out); escapeMsg != "" && msg == "" {
+				msg = escapeMsg
+			}
+		default:
+			out.WriteRune(l.ch)
+		}
+	}
+}
+
+// readRawString reads a string delimited by backticks. Raw strings can span multiple lines
+// and don't have escape sequences, so every character is kept exactly as it is written
+func (l *Lexer) readRawString() (string, string) {
+	position := l.position + 1
+	for {
+		l.readChar()
+		if l.ch == '`' {
+			return l.input[position:l.position], ""
+		}
+		if l.ch == 0 {
+			return "", "unterminated raw string"
 		}
 	}
-	return l.input[position:l.position]
+}
+
+// newStringToken creates a string token, or an illegal token if the string couldn't be read
+func newStringToken(literal string, msg string) token.Token {
+	if msg != "" {
+		return token.Token{Type: token.ILLEGAL, Literal: msg}
+	}
+	return token.Token{Type: token.STRING, Literal: literal}
 }
 
 func (l *Lexer) skipWhitespace() {
@@ -167,25 +446,141 @@ func (l *Lexer) skipWhitespace() {
 	}
 }
 
-func newToken(tokenType token.Type, ch byte) token.Token {
+// skipComment skips over a line comment or a block comment, recording it if comments are
+// being kept. If a block comment is never closed, an ILLEGAL token is returned along with false
+func (l *Lexer) skipComment() (token.Token, bool) {
+	pos := l.currentPosition()
+	position := l.position
+
+	if l.peekChar() == '/' {
+		for l.ch != '\n' && l.ch != 0 {
+			l.readChar()
+		}
+	} else {
+		l.readChar()
+		l.readChar()
+		for !(l.ch == '*' && l.peekChar() == '/') {
+			if l.ch == 0 {
+				return token.Token{Type: token.ILLEGAL, Literal: "unterminated block comment", Pos: pos}, false
+			}
+			l.readChar()
+		}
+		l.readChar()
+		l.readChar()
+	}
+
+	if l.keepComments {
+		comment := token.Token{Type: token.COMMENT, Literal: l.input[position:l.position], Pos: pos}
+		l.comments = append(l.comments, comment)
+	}
+
+	return token.Token{}, true
+}
+
+// newCharToken creates a character token, or an illegal token if the character couldn't be read
+func newCharToken(literal string, msg string) token.Token {
+	if msg != "" {
+		return token.Token{Type: token.ILLEGAL, Literal: msg}
+	}
+	return token.Token{Type: token.CHAR, Literal: literal}
+}
+
+func newToken(tokenType token.Type, ch rune) token.Token {
 	return token.Token{
 		Type:    tokenType,
 		Literal: string(ch),
 	}
 }
 
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
+// newOperatorToken creates a token for an arithmetic operator, or for its compound
+// assignment form if the operator is followed by '='
+func (l *Lexer) newOperatorToken(operator token.Type, assignment token.Type) token.Token {
+	if l.peekChar() == '=' {
+		ch := l.ch
+		l.readChar()
+		literal := string(ch) + string(l.ch)
+		return token.Token{Type: assignment, Literal: literal}
+	}
+	return newToken(operator, l.ch)
+}
+
+// newDoubleCharToken creates a token for operators that are made of the same character
+// twice, such as '&&'. If the character isn't repeated, the token is illegal
+func (l *Lexer) newDoubleCharToken(tokenType token.Type) token.Token {
+	if l.peekChar() == l.ch {
+		ch := l.ch
+		l.readChar()
+		literal := string(ch) + string(l.ch)
+		return token.Token{Type: tokenType, Literal: literal}
+	}
+	return newToken(token.ILLEGAL, l.ch)
+}
+
+func (l *Lexer) peekChar() rune {
+	return l.peekCharAt(1)
+}
+
+// peekCharAt returns the character the specified number of characters after the pointer
+// without moving the pointer
+func (l *Lexer) peekCharAt(distance int) rune {
+	position := l.readPosition
+	for ; distance > 1 && position < len(l.input); distance-- {
+		_, width := utf8.DecodeRuneInString(l.input[position:])
+		position += width
+	}
+	if position >= len(l.input) {
 		return 0
 	}
-	return l.input[l.readPosition]
+	ch, _ := utf8.DecodeRuneInString(l.input[position:])
+	return ch
+}
+
+// isLetter checks if the character can be used in an identifier, which can be made of any
+// Unicode letters and underscores
+func isLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
+}
+
+// numberBase returns the name and digit check of the base that the character after a leading
+// zero is a prefix for, or nil if the character isn't a base prefix
+func numberBase(ch rune) (string, func(rune) bool) {
+	switch ch {
+	case 'x', 'X':
+		return "hexadecimal", isHexDigit
+	case 'o', 'O':
+		return "octal", isOctalDigit
+	case 'b', 'B':
+		return "binary", isBinaryDigit
+	}
+	return "", nil
+}
+
+// hasValidSeparators checks that every underscore in a number is between two digits
+func hasValidSeparators(literal string, isDigit func(rune) bool) bool {
+	runes := []rune(literal)
+	for i, ch := range runes {
+		if ch != '_' {
+			continue
+		}
+		if i == 0 || i == len(runes)-1 || !isDigit(runes[i-1]) || !isDigit(runes[i+1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isHexDigit(ch rune) bool {
+	return isInteger(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+func isOctalDigit(ch rune) bool {
+	return '0' <= ch && ch <= '7'
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+func isBinaryDigit(ch rune) bool {
+	return ch == '0' || ch == '1'
 }
 
-//TODO add float support here
-func isInteger(ch byte) bool {
+func isInteger(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
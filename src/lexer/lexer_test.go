@@ -13,7 +13,7 @@ func TestNextToken(t *testing.T) {
 		x + y;
 	};
 	var result = add(five, ten);
-	!-/*5;
+	!-/ *5;
 	5 < 10 > 5;
 	if (5 < 10) {
 		return true;
@@ -146,3 +146,449 @@ func TestNextToken(t *testing.T) {
 		}
 	}
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := `var five = 5;
+  five + "ten";`
+
+	tests := []struct {
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+		expectedOffset  int
+	}{
+		{"var", 1, 1, 0},
+		{"five", 1, 5, 4},
+		{"=", 1, 10, 9},
+		{"5", 1, 12, 11},
+		{";", 1, 13, 12},
+		{"five", 2, 3, 16},
+		{"+", 2, 8, 21},
+		{"ten", 2, 10, 23},
+		{";", 2, 15, 28},
+		{"", 2, 16, 29},
+	}
+
+	l := NewFile("test.brisk", input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token literal wrong. expected %q got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Pos.Filename != "test.brisk" {
+			t.Fatalf("tests[%d] - token filename wrong. expected %q got %q", i, "test.brisk", tok.Pos.Filename)
+		}
+
+		if tok.Pos.Line != tt.expectedLine || tok.Pos.Column != tt.expectedColumn {
+			t.Fatalf("tests[%d] - token position wrong. expected %d:%d got %d:%d", i, tt.expectedLine, tt.expectedColumn, tok.Pos.Line, tok.Pos.Column)
+		}
+
+		if tok.Pos.Offset != tt.expectedOffset {
+			t.Fatalf("tests[%d] - token offset wrong. expected %d got %d", i, tt.expectedOffset, tok.Pos.Offset)
+		}
+	}
+}
+
+func TestNumberTokens(t *testing.T) {
+	input := `5 3.14 0.5 1e-9 2.5E+3 7e2 10.x 4e`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.INT, "5"},
+		{token.FLOAT, "3.14"},
+		{token.FLOAT, "0.5"},
+		{token.FLOAT, "1e-9"},
+		{token.FLOAT, "2.5E+3"},
+		{token.FLOAT, "7e2"},
+		{token.INT, "10"},
+		{token.ILLEGAL, "."},
+		{token.IDENT, "x"},
+		{token.INT, "4"},
+		{token.IDENT, "e"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected %q got %q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token literal wrong. expected %q got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestIntegerBases(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{"0x1F", token.INT, "0x1F"},
+		{"0XabCD", token.INT, "0XabCD"},
+		{"0o17", token.INT, "0o17"},
+		{"0b1010", token.INT, "0b1010"},
+		{"1_000_000", token.INT, "1_000_000"},
+		{"0b1111_0000", token.INT, "0b1111_0000"},
+		{"1_000.000_1", token.FLOAT, "1_000.000_1"},
+		{"0xZZ", token.ILLEGAL, "malformed hexadecimal literal: invalid digit 'Z' in 0xZZ"},
+		{"0o8", token.ILLEGAL, "malformed octal literal: invalid digit '8' in 0o8"},
+		{"0b102", token.ILLEGAL, "malformed binary literal: invalid digit '2' in 0b102"},
+		{"0x", token.ILLEGAL, "malformed hexadecimal literal: 0x has no digits"},
+		{"0b_", token.ILLEGAL, "malformed binary literal: 0b_ has no digits"},
+		{"1_000_", token.ILLEGAL, "'_' must separate successive digits: 1_000_"},
+		{"1__000", token.ILLEGAL, "'_' must separate successive digits: 1__000"},
+		{"1_.5", token.ILLEGAL, "'_' must separate successive digits: 1_.5"},
+		{"0x_1F", token.ILLEGAL, "'_' must separate successive digits: 0x_1F"},
+		{"0123", token.ILLEGAL, "malformed decimal literal: leading zero in 0123"},
+		{"09", token.ILLEGAL, "malformed decimal literal: leading zero in 09"},
+		{"0_1", token.ILLEGAL, "malformed decimal literal: leading zero in 0_1"},
+		{"0", token.INT, "0"},
+		{"0.5", token.FLOAT, "0.5"},
+		{"0e3", token.FLOAT, "0e3"},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected %q got %q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token literal wrong. expected %q got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok = l.NextToken(); tok.Type != token.EOF {
+			t.Fatalf("tests[%d] - expected EOF after number. got %q", i, tok.Type)
+		}
+	}
+}
+
+func TestLoopKeywords(t *testing.T) {
+	input := `while for in break continue`
+
+	tests := []token.Type{
+		token.COMMAND_WHILE,
+		token.COMMAND_FOR,
+		token.COMMAND_IN,
+		token.COMMAND_BREAK,
+		token.COMMAND_CONTINUE,
+		token.EOF,
+	}
+
+	l := New(input)
+
+	for i, expectedType := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected %q got %q", i, expectedType, tok.Type)
+		}
+	}
+}
+
+func TestAssignmentOperators(t *testing.T) {
+	input := `x = 1; x += 2; x -= 3; x *= 4; x /= 5; x %= 6;`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.VAR_EQUALS, "="},
+		{token.PLUS_EQUALS, "+="},
+		{token.MINUS_EQUALS, "-="},
+		{token.MULTIPLY_EQUALS, "*="},
+		{token.DIVIDE_EQUALS, "/="},
+		{token.MOD_EQUALS, "%="},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		l.NextToken()
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected %q got %q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token literal wrong. expected %q got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		l.NextToken()
+		l.NextToken()
+	}
+}
+
+func TestLogicalOperators(t *testing.T) {
+	input := `a and b or c && d || e & f`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.LOGICAL_AND, "and"},
+		{token.IDENT, "b"},
+		{token.LOGICAL_OR, "or"},
+		{token.IDENT, "c"},
+		{token.LOGICAL_AND, "&&"},
+		{token.IDENT, "d"},
+		{token.LOGICAL_OR, "||"},
+		{token.IDENT, "e"},
+		{token.ILLEGAL, "&"},
+		{token.IDENT, "f"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected %q got %q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token literal wrong. expected %q got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// a line comment
+var x = 10 / 2; // trailing comment
+/* a block
+   comment */ x /= 2;
+/**/x`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.VAR_DECLARATION, "var"},
+		{token.IDENT, "x"},
+		{token.VAR_EQUALS, "="},
+		{token.INT, "10"},
+		{token.DIVIDE, "/"},
+		{token.INT, "2"},
+		{token.END_OF_LINE, ";"},
+		{token.IDENT, "x"},
+		{token.DIVIDE_EQUALS, "/="},
+		{token.INT, "2"},
+		{token.END_OF_LINE, ";"},
+		{token.IDENT, "x"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+	l.KeepComments()
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected %q got %q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token literal wrong. expected %q got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+
+	expectedComments := []struct {
+		literal string
+		line    int
+		column  int
+	}{
+		{"// a line comment", 1, 1},
+		{"// trailing comment", 2, 17},
+		{"/* a block\n   comment */", 3, 1},
+		{"/**/", 5, 1},
+	}
+
+	comments := l.Comments()
+	if len(comments) != len(expectedComments) {
+		t.Fatalf("wrong number of comments. expected %d got %d", len(expectedComments), len(comments))
+	}
+
+	for i, expected := range expectedComments {
+		comment := comments[i]
+
+		if comment.Type != token.COMMENT || comment.Literal != expected.literal {
+			t.Errorf("comments[%d] - wrong comment. expected %q got %s %q", i, expected.literal, comment.Type, comment.Literal)
+		}
+
+		if comment.Pos.Line != expected.line || comment.Pos.Column != expected.column {
+			t.Errorf("comments[%d] - wrong position. expected %d:%d got %d:%d", i, expected.line, expected.column, comment.Pos.Line, comment.Pos.Column)
+		}
+	}
+}
+
+func TestCommentsNotKeptByDefault(t *testing.T) {
+	l := New("// comment\nx")
+
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+	}
+
+	if len(l.Comments()) != 0 {
+		t.Errorf("expected no comments to be kept. got=%d", len(l.Comments()))
+	}
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	l := New("x /* never closed")
+
+	tok := l.NextToken()
+	if tok.Type != token.IDENT {
+		t.Fatalf("token type wrong. expected %q got %q", token.IDENT, tok.Type)
+	}
+
+	tok = l.NextToken()
+	if tok.Type != token.ILLEGAL {
+		t.Fatalf("token type wrong. expected %q got %q", token.ILLEGAL, tok.Type)
+	}
+
+	if tok.Literal != "unterminated block comment" {
+		t.Errorf("token literal wrong. got %q", tok.Literal)
+	}
+
+	if tok.Pos.Column != 3 {
+		t.Errorf("token column wrong. expected 3 got %d", tok.Pos.Column)
+	}
+
+	tok = l.NextToken()
+	if tok.Type != token.EOF {
+		t.Fatalf("token type wrong. expected %q got %q", token.EOF, tok.Type)
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{`"line\nbreak"`, token.STRING, "line\nbreak"},
+		{`"tab\there"`, token.STRING, "tab\there"},
+		{`"back\\slash"`, token.STRING, `back\slash`},
+		{`"say \"hi\""`, token.STRING, `say "hi"`},
+		{`"\u{48}\u{e9}\u{1F600}"`, token.STRING, "Hé😀"},
+		{"`raw \\n \"string\"\nover lines`", token.STRING, "raw \\n \"string\"\nover lines"},
+		{`"never closed`, token.ILLEGAL, "unterminated string"},
+		{`"ends in escape\`, token.ILLEGAL, "unterminated string"},
+		{"`never closed", token.ILLEGAL, "unterminated raw string"},
+		{`"bad \q escape"`, token.ILLEGAL, `unknown escape sequence: \q`},
+		{`"\u48"`, token.ILLEGAL, `invalid unicode escape: expected { after \u`},
+		{`"\u{48"`, token.ILLEGAL, `invalid unicode escape: \u{48`},
+		{`"\u{110000}"`, token.ILLEGAL, `invalid unicode escape: \u{110000}`},
+		{`"\u{}"`, token.ILLEGAL, `invalid unicode escape: \u{}`},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected %q got %q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token literal wrong. expected %q got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok = l.NextToken(); tok.Type != token.EOF {
+			t.Fatalf("tests[%d] - expected EOF after string. got %q", i, tok.Type)
+		}
+	}
+}
+
+func TestCharLiterals(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{`'a'`, token.CHAR, "a"},
+		{`'é'`, token.CHAR, "é"},
+		{`'\n'`, token.CHAR, "\n"},
+		{`'\''`, token.CHAR, "'"},
+		{`'"'`, token.CHAR, `"`},
+		{`'\u{1F600}'`, token.CHAR, "😀"},
+		{`''`, token.ILLEGAL, "empty character literal"},
+		{`'ab'`, token.ILLEGAL, "character literal must contain exactly one character"},
+		{`'a`, token.ILLEGAL, "unterminated character literal"},
+		{`'\q'`, token.ILLEGAL, `unknown escape sequence: \q`},
+	}
+
+	for i, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected %q got %q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token literal wrong. expected %q got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok = l.NextToken(); tok.Type != token.EOF {
+			t.Fatalf("tests[%d] - expected EOF after character. got %q", i, tok.Type)
+		}
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	input := `var café = "héllo"; naïve + 日本;`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+		expectedColumn  int
+		expectedOffset  int
+	}{
+		{token.VAR_DECLARATION, "var", 1, 0},
+		{token.IDENT, "café", 5, 4},
+		{token.VAR_EQUALS, "=", 10, 10},
+		{token.STRING, "héllo", 12, 12},
+		{token.END_OF_LINE, ";", 19, 20},
+		{token.IDENT, "naïve", 21, 22},
+		{token.PLUS, "+", 27, 29},
+		{token.IDENT, "日本", 29, 31},
+		{token.END_OF_LINE, ";", 31, 37},
+		{token.EOF, "", 32, 38},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - token type wrong. expected %q got %q", i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - token literal wrong. expected %q got %q", i, tt.expectedLiteral, tok.Literal)
+		}
+
+		if tok.Pos.Column != tt.expectedColumn || tok.Pos.Offset != tt.expectedOffset {
+			t.Fatalf("tests[%d] - token position wrong. expected column %d offset %d got column %d offset %d", i, tt.expectedColumn, tt.expectedOffset, tok.Pos.Column, tok.Pos.Offset)
+		}
+	}
+}
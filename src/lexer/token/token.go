@@ -1,5 +1,7 @@
 package token
 
+import "fmt"
+
 // Type is the expected string value of the type of the token found
 type Type string
 
@@ -8,15 +10,46 @@ type Type string
 type Token struct {
 	Type    Type
 	Literal string
+	Pos     Position
+}
+
+// Position represents the location of a token in BRISK source code. Lines and
+// columns both start at 1, while the byte offset starts at 0
+type Position struct {
+	Filename string
+	Line     int
+	Column   int
+	Offset   int
+}
+
+// IsValid returns true if the position points to a location in source code
+func (p Position) IsValid() bool { return p.Line > 0 }
+
+// String returns the position in the form file:line:column, leaving out the
+// file name if there isn't one
+func (p Position) String() string {
+	if !p.IsValid() {
+		if p.Filename != "" {
+			return p.Filename
+		}
+		return "-"
+	}
+	if p.Filename != "" {
+		return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
 }
 
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
+	COMMENT = "COMMENT"
 
 	IDENT  = "IDENT"
 	INT    = "INT"
+	FLOAT  = "FLOAT"
 	STRING = "STRING"
+	CHAR   = "CHAR"
 
 	END_OF_LINE = ";" // nolint: golint
 	NEWLINE     = "\n"
@@ -49,13 +82,22 @@ const (
 	MOD      = "%"
 	MULTIPLY = "*"
 
+	PLUS_EQUALS     = "+="
+	MINUS_EQUALS    = "-="
+	DIVIDE_EQUALS   = "/="
+	MOD_EQUALS      = "%="
+	MULTIPLY_EQUALS = "*="
+
 	FUNCTION        = "func"
 	FUNCTION_RETURN = "return"
 
-	COMMAND_IF    = "if"
-	COMMAND_ELSE  = "else"
-	COMMAND_FOR   = "for"
-	COMMAND_WHILE = "while"
+	COMMAND_IF       = "if"
+	COMMAND_ELSE     = "else"
+	COMMAND_FOR      = "for"
+	COMMAND_IN       = "in"
+	COMMAND_WHILE    = "while"
+	COMMAND_BREAK    = "break"
+	COMMAND_CONTINUE = "continue"
 
 	CONDITION_EQUALS          = "=="
 	CONDITION_NOT_EQUAL       = "!="
@@ -65,18 +107,43 @@ const (
 	CONDITION_LESS_THAN       = "<"
 	CONDITION_LESS_THAN_EQUAL = "<="
 
+	LOGICAL_AND = "&&"
+	LOGICAL_OR  = "||"
+
 	BOOL_TRUE  = "true"
 	BOOL_FALSE = "false"
 )
 
 var keywords = map[string]Type{
-	"func":   FUNCTION,
-	"var":    VAR_DECLARATION,
-	"if":     COMMAND_IF,
-	"else":   COMMAND_ELSE,
-	"return": FUNCTION_RETURN,
-	"true":   BOOL_TRUE,
-	"false":  BOOL_FALSE,
+	"func":     FUNCTION,
+	"var":      VAR_DECLARATION,
+	"if":       COMMAND_IF,
+	"else":     COMMAND_ELSE,
+	"for":      COMMAND_FOR,
+	"in":       COMMAND_IN,
+	"while":    COMMAND_WHILE,
+	"break":    COMMAND_BREAK,
+	"continue": COMMAND_CONTINUE,
+	"return":   FUNCTION_RETURN,
+	"true":     BOOL_TRUE,
+	"false":    BOOL_FALSE,
+	"and":      LOGICAL_AND,
+	"or":       LOGICAL_OR,
+}
+
+var types = map[string]bool{
+	TYPE_INT:    true,
+	TYPE_STR:    true,
+	TYPE_BOOL:   true,
+	TYPE_DOUBLE: true,
+	TYPE_FLOAT:  true,
+	TYPE_CHAR:   true,
+}
+
+// IsType checks whether the identifier is the name of one of the BRISK types that variables,
+// parameters and return values can be annotated with
+func IsType(ident string) bool {
+	return types[ident]
 }
 
 // LookupIdent checks the keywords map to see if the string parsed is a BRISK command
@@ -0,0 +1,265 @@
+// Package optimizer simplifies BRISK programs before they are run. Arithmetic on constant
+// integers, strings and booleans is worked out once instead of every time it is evaluated,
+// the branches of if expressions that can never be taken are removed, and so are statements
+// that can never be reached because they come after a return, break or continue statement
+package optimizer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/kai119/Brisk/src/evaluator"
+	"github.com/kai119/Brisk/src/evaluator/object"
+	"github.com/kai119/Brisk/src/lexer/token"
+	"github.com/kai119/Brisk/src/parser/ast"
+)
+
+// Error is a constant expression that would fail when it is evaluated, along with the position
+// in the source code where it was found
+type Error struct {
+	Pos     token.Position
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Pos.IsValid() {
+		return e.Pos.String() + ": " + e.Message
+	}
+	return e.Message
+}
+
+// Optimizer rewrites the AST of a program, replacing each node with a simpler one that gives
+// the same result. Constant expressions that would fail are left in place and reported, so
+// that the error can be found before the program is run
+type Optimizer struct {
+	errors []*Error
+
+	// failures holds the message of each constant expression that would fail. They are only
+	// reported if they are still in the program once it has been rewritten, since code that
+	// can never run, such as the branch of an if expression that is never taken, can't fail
+	failures map[ast.Node]string
+}
+
+// New creates a new optimizer
+func New() *Optimizer {
+	return &Optimizer{failures: make(map[ast.Node]string)}
+}
+
+// Optimize rewrites a program in place, returning all of the errors it finds sorted by their
+// position
+func Optimize(program *ast.Program) []*Error {
+	o := New()
+	o.Optimize(program)
+	return o.Errors()
+}
+
+// Optimize rewrites a program in place. The errors it finds in the code that is left are added
+// to the optimizer's errors
+func (o *Optimizer) Optimize(program *ast.Program) {
+	ast.Modify(program, o.optimize)
+
+	ast.Inspect(program, func(node ast.Node) bool {
+		if msg, ok := o.failures[node]; ok {
+			o.addError(node.Pos(), "%s", msg)
+		}
+		return true
+	})
+	o.failures = make(map[ast.Node]string)
+}
+
+// Errors returns all errors that the optimizer has found, sorted by their position
+func (o *Optimizer) Errors() []*Error {
+	sort.SliceStable(o.errors, func(i, j int) bool {
+		a, b := o.errors[i].Pos, o.errors[j].Pos
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return o.errors
+}
+
+func (o *Optimizer) addError(pos token.Position, format string, a ...interface{}) {
+	o.errors = append(o.errors, &Error{Pos: pos, Message: fmt.Sprintf(format, a...)})
+}
+
+// optimize is called by ast.Modify for each node, after the node's children have already
+// been optimized
+func (o *Optimizer) optimize(node ast.Node) ast.Node {
+	switch node := node.(type) {
+	case *ast.PrefixExpression:
+		return o.foldPrefix(node)
+	case *ast.InfixExpression:
+		if node.Token.Type == token.LOGICAL_AND || node.Token.Type == token.LOGICAL_OR {
+			return foldLogical(node)
+		}
+		return o.foldInfix(node)
+	case *ast.IfExpression:
+		return removeDeadBranch(node)
+	case *ast.BlockStatement:
+		node.Statements = removeUnreachable(node.Statements)
+	case *ast.Program:
+		node.Statements = removeUnreachable(node.Statements)
+	}
+
+	return node
+}
+
+func (o *Optimizer) foldPrefix(node *ast.PrefixExpression) ast.Expression {
+	right, ok := constant(node.Right)
+	if !ok {
+		return node
+	}
+
+	return o.fold(node, evaluator.PrefixOperation(node.Operator, right))
+}
+
+func (o *Optimizer) foldInfix(node *ast.InfixExpression) ast.Expression {
+	left, ok := constant(node.Left)
+	if !ok {
+		return node
+	}
+	right, ok := constant(node.Right)
+	if !ok {
+		return node
+	}
+
+	// repeating a string is left until the program is run, so that the optimizer doesn't
+	// build strings that may never be needed
+	if node.Operator == "*" && isRepeat(left, right) {
+		return node
+	}
+
+	return o.fold(node, evaluator.InfixOperation(node.Operator, left, right))
+}
+
+// fold replaces an expression with the constant that it results in, or leaves the expression
+// in place and records the error that it results in
+func (o *Optimizer) fold(node ast.Expression, result object.Object) ast.Expression {
+	if err, ok := result.(*object.Error); ok {
+		o.failures[node] = err.Message
+		return node
+	}
+
+	if folded, ok := literal(result, node.Pos()); ok {
+		return folded
+	}
+	return node
+}
+
+// foldLogical folds an 'and' or 'or' expression when its result is already known. The right
+// side is only removed when the left side decides the result, since that is the only time that
+// it wouldn't be evaluated
+func foldLogical(node *ast.InfixExpression) ast.Expression {
+	isAnd := node.Token.Type == token.LOGICAL_AND
+
+	if left, ok := constant(node.Left); ok {
+		if evaluator.IsTruthy(left) != isAnd {
+			return newBoolean(!isAnd, node.Pos())
+		}
+		if right, ok := constant(node.Right); ok {
+			return newBoolean(evaluator.IsTruthy(right), node.Pos())
+		}
+	}
+
+	return node
+}
+
+// removeDeadBranch removes the branch of an if expression that can never be taken because its
+// condition is a constant. If the alternative is the only branch that can be taken, it becomes
+// the consequence of an if expression whose condition is always true
+func removeDeadBranch(node *ast.IfExpression) ast.Expression {
+	condition, ok := constant(node.Condition)
+	if !ok {
+		return node
+	}
+
+	if evaluator.IsTruthy(condition) {
+		node.Alternative = nil
+		return node
+	}
+
+	if node.Alternative != nil {
+		node.Condition = newBoolean(true, node.Condition.Pos())
+		node.Consequence = node.Alternative
+		node.Alternative = nil
+		return node
+	}
+
+	node.Consequence = &ast.BlockStatement{Token: node.Consequence.Token}
+	return node
+}
+
+// removeUnreachable removes the statements that come after a return, break or continue
+// statement. Named functions are kept, since they are declared before any of the statements
+// around them are run and could still be called
+func removeUnreachable(statements []ast.Statement) []ast.Statement {
+	for i, statement := range statements {
+		switch statement.(type) {
+		case *ast.ReturnStatement, *ast.BreakStatement, *ast.ContinueStatement:
+			reachable := statements[:i+1]
+			for _, unreachable := range statements[i+1:] {
+				if fs, ok := unreachable.(*ast.FunctionStatement); ok {
+					reachable = append(reachable, fs)
+				}
+			}
+			return reachable
+		}
+	}
+
+	return statements
+}
+
+// constant returns the value of an integer, string or boolean literal
+func constant(exp ast.Expression) (object.Object, bool) {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return &object.Integer{Value: exp.Value}, true
+	case *ast.StringLiteral:
+		return &object.String{Value: exp.Value}, true
+	case *ast.Boolean:
+		if exp.Value {
+			return evaluator.TRUE, true
+		}
+		return evaluator.FALSE, true
+	default:
+		return nil, false
+	}
+}
+
+// literal makes the literal for a constant integer, string or boolean value
+func literal(obj object.Object, pos token.Position) (ast.Expression, bool) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		tok := token.Token{Type: token.INT, Literal: strconv.FormatInt(obj.Value, 10), Pos: pos}
+		return &ast.IntegerLiteral{Token: tok, Value: obj.Value}, true
+	case *object.String:
+		tok := token.Token{Type: token.STRING, Literal: obj.Value, Pos: pos}
+		return &ast.StringLiteral{Token: tok, Value: obj.Value}, true
+	case *object.Boolean:
+		return newBoolean(obj.Value, pos), true
+	default:
+		return nil, false
+	}
+}
+
+func newBoolean(value bool, pos token.Position) *ast.Boolean {
+	tok := token.Token{Type: token.BOOL_FALSE, Literal: "false", Pos: pos}
+	if value {
+		tok = token.Token{Type: token.BOOL_TRUE, Literal: "true", Pos: pos}
+	}
+	return &ast.Boolean{Token: tok, Value: value}
+}
+
+// isRepeat reports whether multiplying the values repeats a string a non-negative number
+// of times. A negative count is an error, which is still reported
+func isRepeat(left, right object.Object) bool {
+	str, count := left, right
+	if left.Type() == object.INTEGER_OBJ {
+		str, count = right, left
+	}
+
+	n, ok := count.(*object.Integer)
+	return ok && str.Type() == object.STRING_OBJ && n.Value >= 0
+}
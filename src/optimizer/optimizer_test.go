@@ -0,0 +1,135 @@
+package optimizer
+
+import (
+	"testing"
+
+	"github.com/kai119/Brisk/src/evaluator"
+	"github.com/kai119/Brisk/src/evaluator/object"
+	"github.com/kai119/Brisk/src/lexer"
+	"github.com/kai119/Brisk/src/parser"
+	"github.com/kai119/Brisk/src/parser/ast"
+)
+
+func TestOptimize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"60 * 60 * 24", "86400"},
+		{"-5 + 1", "-4"},
+		{"7 % 3 - 10 / 4", "-1"},
+		{`"con" + "cat"`, "concat"},
+		{`"a" < "b"`, "true"},
+		{"1 < 2 == true", "true"},
+		{"!true", "false"},
+		{"!0", "false"},
+		{"false and x", "false"},
+		{"true or x", "true"},
+		{"true and false", "false"},
+		{"x and true", "(x and true)"},
+		{"1.5 * 2", "(1.5 * 2)"},
+		{`"ab" * 3`, "(ab * 3)"},
+		{"x + 2 * 3", "(x + 6)"},
+		{"if (true) { a } else { b }", "iftrue a"},
+		{"if (1 > 2) { a } else { b }", "iftrue b"},
+		{"if (false) { a }", "iffalse "},
+		{"if (x) { 1 + 1 } else { 2 + 2 }", "ifx 2else4"},
+		{"func f() { return 1; 2; func g() { 3 } }", "func f()return 1;func g()3"},
+		{"while (x) { break; x = 1 }", "whilex break;"},
+		{"for (v in arr) { continue; v }", "for (v in arr) continue;"},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		if errors := Optimize(program); len(errors) != 0 {
+			t.Fatalf("optimizer has errors for %q: %v", tt.input, errors)
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("wrong program for %q. expected=%q, got=%q", tt.input, tt.expected, program.String())
+		}
+	}
+}
+
+func TestOptimizeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"10 / 0", []string{"1:1: division by zero"}},
+		{"var x = 1 + 10 % (5 - 5);", []string{"1:13: modulo by zero"}},
+		{"1 + true", []string{"1:1: type mismatch: INTEGER + BOOLEAN"}},
+		{"-true", []string{"1:1: unknown operator: -BOOLEAN"}},
+		{`"ab" * -1`, []string{"1:1: negative repeat count: -1"}},
+		{"func f() {\n  if (true) { 1 / 0 }\n}\n1 - \"a\"", []string{
+			"2:15: division by zero",
+			"4:1: unknown operator STRING - INTEGER",
+		}},
+		{"if (false) { 1 / 0 }", []string{}},
+		{"if (1 > 2) { 1 + true } else { 2 % 0 }", []string{"1:32: modulo by zero"}},
+		{"func f() { return 1; 1 / 0 }", []string{}},
+		{"false and 1 / 0", []string{}},
+		{"var x = 1; x and 1 / 0", []string{"1:18: division by zero"}},
+	}
+
+	for _, tt := range tests {
+		errors := Optimize(parse(t, tt.input))
+		if len(errors) != len(tt.expected) {
+			t.Errorf("wrong number of errors for %q. expected=%q, got=%v", tt.input, tt.expected, errors)
+			continue
+		}
+		for i, err := range errors {
+			if err.Error() != tt.expected[i] {
+				t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected[i], err.Error())
+			}
+		}
+	}
+}
+
+func TestOptimizedResults(t *testing.T) {
+	tests := []string{
+		"var day = 60 * 60 * 24; day * 2",
+		`var s = "a" + "b"; s + "c"`,
+		"if (1 == 1) { 10 } else { 20 }",
+		"if (1 == 2) { 10 }",
+		"if (1 == 2) { 10 } else { if (2 == 2) { 20 } else { 30 } }",
+		"func f() { if (false) { return 1 }; return g(); 5; func g() { 2 } }; f()",
+		"var total = 0; for (var i = 0; i < 5; i += 1) { if (i == 3) { break; total = 100 }; total += i }; total",
+		"var called = false; var f = func() { called = true }; false and f(); called",
+		"1 == true",
+		"-9223372036854775807 - 1",
+		"return 1 + 2; 4",
+	}
+
+	for _, input := range tests {
+		expected := evaluator.Eval(parse(t, input), object.NewEnvironment())
+
+		program := parse(t, input)
+		if errors := Optimize(program); len(errors) != 0 {
+			t.Fatalf("optimizer has errors for %q: %v", input, errors)
+		}
+		result := evaluator.Eval(program, object.NewEnvironment())
+
+		if inspect(result) != inspect(expected) {
+			t.Errorf("optimized program gives a different result for %q. expected=%s, got=%s", input, inspect(expected), inspect(result))
+		}
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors for %q: %q", input, p.Errors())
+	}
+	return program
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return "<nil>"
+	}
+	return obj.Inspect()
+}
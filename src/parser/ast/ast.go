@@ -8,9 +8,11 @@ import (
 )
 
 // Node represents a node on the AST. It contains the token literal for that node
+// and the position in the source code where the node starts
 type Node interface {
 	TokenLiteral() string
 	String() string
+	Pos() token.Position
 }
 
 // Statement represents a statement and is attached to a node on the AST
@@ -39,6 +41,14 @@ func (p *Program) TokenLiteral() string {
 	return ""
 }
 
+// Pos returns the position of the first statement in the program
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 
@@ -52,11 +62,30 @@ func (p *Program) String() string {
 	return out.String()
 }
 
-// VarStatement is an example of a variable declaration eg. var num = 5
+// Destructure is the way that a variable declaration unpacks its value into several variables
+type Destructure int
+
+// The ways that a variable declaration can unpack its value
+const (
+	// NoDestructure declares a single variable, eg. var num = 5
+	NoDestructure Destructure = iota
+	// TupleDestructure unpacks the values returned by a function, eg. var x, y = f()
+	TupleDestructure
+	// ArrayDestructure unpacks the elements of an array, eg. var [a, b] = arr
+	ArrayDestructure
+	// DictionaryDestructure unpacks the values of a dictionary's keys, eg. var {name, age} = dict
+	DictionaryDestructure
+)
+
+// VarStatement is an example of a variable declaration eg. var num = 5. A declaration that
+// destructures its value has the names of its variables in Names instead of Name
 type VarStatement struct {
-	Token token.Token
-	Name  *Identifier
-	Value Expression
+	Token       token.Token
+	Name        *Identifier
+	Names       []*Identifier
+	Destructure Destructure
+	Type        *Type
+	Value       Expression
 }
 
 func (vs *VarStatement) statementNode() {}
@@ -64,6 +93,9 @@ func (vs *VarStatement) statementNode() {}
 //TokenLiteral returns the token literal in the variable statement
 func (vs *VarStatement) TokenLiteral() string { return vs.Token.Literal }
 
+// Pos returns the position of the variable statement
+func (vs *VarStatement) Pos() token.Position { return vs.Token.Pos }
+
 func (vs *VarStatement) String() string {
 	var out bytes.Buffer
 
@@ -71,10 +103,16 @@ func (vs *VarStatement) String() string {
 	if err != nil {
 		return ""
 	}
-	_, err = out.WriteString(vs.Name.String())
+	_, err = out.WriteString(vs.target())
 	if err != nil {
 		return ""
 	}
+	if vs.Type != nil {
+		_, err = out.WriteString(" " + vs.Type.String())
+		if err != nil {
+			return ""
+		}
+	}
 	_, err = out.WriteString(" = ")
 	if err != nil {
 		return ""
@@ -95,11 +133,47 @@ func (vs *VarStatement) String() string {
 	return out.String()
 }
 
+// target returns the variable, or the destructuring pattern, that the statement declares
+func (vs *VarStatement) target() string {
+	if vs.Destructure == NoDestructure {
+		return vs.Name.String()
+	}
+
+	names := []string{}
+	for _, name := range vs.Names {
+		names = append(names, name.String())
+	}
+	joined := strings.Join(names, ", ")
+
+	switch vs.Destructure {
+	case ArrayDestructure:
+		return "[" + joined + "]"
+	case DictionaryDestructure:
+		return "{" + joined + "}"
+	default:
+		return joined
+	}
+}
+
 // Identifier represents the identifier of a variable. For example, in the
 // statement 'var num = 5', 'num' is the identifier
 type Identifier struct {
 	Token token.Token
 	Value string
+	// Binding is where the variable is stored, which is filled in by the resolver. It is nil
+	// if the identifier hasn't been resolved, in which case the variable is looked up by name
+	Binding *Binding
+}
+
+// Binding is the location of a variable that an identifier refers to, worked out by the
+// resolver before the program is run
+type Binding struct {
+	// Depth is the number of environments out from the one the identifier is used in that
+	// the variable is stored in
+	Depth int
+	// Slot is the index of the variable in its environment, or -1 for global variables,
+	// which are looked up by name
+	Slot int
 }
 
 func (i *Identifier) expressionNode() {}
@@ -107,8 +181,26 @@ func (i *Identifier) expressionNode() {}
 //TokenLiteral returns the token literal of the identifier
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
 
+// Pos returns the position of the identifier
+func (i *Identifier) Pos() token.Position { return i.Token.Pos }
+
 func (i *Identifier) String() string { return i.Value }
 
+// Type represents a type annotation on a variable, parameter or return value. For example,
+// in the statement 'var num int = 5', 'int' is the type
+type Type struct {
+	Token token.Token
+	Name  string
+}
+
+// TokenLiteral returns the token literal of the type
+func (t *Type) TokenLiteral() string { return t.Token.Literal }
+
+// Pos returns the position of the type
+func (t *Type) Pos() token.Position { return t.Token.Pos }
+
+func (t *Type) String() string { return t.Name }
+
 // ReturnStatement represents a return statement. For example, a
 // return statement could be 'return 5 + 5'
 type ReturnStatement struct {
@@ -121,6 +213,9 @@ func (rs *ReturnStatement) statementNode() {}
 //TokenLiteral returns the token literal of the return statement
 func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
 
+// Pos returns the position of the return statement
+func (rs *ReturnStatement) Pos() token.Position { return rs.Token.Pos }
+
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
 
@@ -156,6 +251,9 @@ func (es *ExpressionStatement) statementNode() {}
 //TokenLiteral returns the token literal of the expression statement
 func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
 
+// Pos returns the position of the expression statement
+func (es *ExpressionStatement) Pos() token.Position { return es.Token.Pos }
+
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -175,8 +273,27 @@ func (il *IntegerLiteral) expressionNode() {}
 //TokenLiteral returns the token literal of the integer
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 
+// Pos returns the position of the integer
+func (il *IntegerLiteral) Pos() token.Position { return il.Token.Pos }
+
 func (il *IntegerLiteral) String() string { return il.Token.Literal }
 
+// FloatLiteral represents a floating-point number
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+
+// TokenLiteral returns the token literal of the float
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+
+// Pos returns the position of the float
+func (fl *FloatLiteral) Pos() token.Position { return fl.Token.Pos }
+
+func (fl *FloatLiteral) String() string { return fl.Token.Literal }
+
 // StringLiteral represents an string
 type StringLiteral struct {
 	Token token.Token
@@ -188,8 +305,27 @@ func (sl *StringLiteral) expressionNode() {}
 //TokenLiteral returns the token literal of the integer
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 
+// Pos returns the position of the string
+func (sl *StringLiteral) Pos() token.Position { return sl.Token.Pos }
+
 func (sl *StringLiteral) String() string { return sl.Token.Literal }
 
+// CharLiteral represents a single character
+type CharLiteral struct {
+	Token token.Token
+	Value rune
+}
+
+func (cl *CharLiteral) expressionNode() {}
+
+// TokenLiteral returns the token literal of the character
+func (cl *CharLiteral) TokenLiteral() string { return cl.Token.Literal }
+
+// Pos returns the position of the character
+func (cl *CharLiteral) Pos() token.Position { return cl.Token.Pos }
+
+func (cl *CharLiteral) String() string { return cl.Token.Literal }
+
 // PrefixExpression represents a prefix expression. Prefix expressions can either
 // be !<expression> or -<expression>
 type PrefixExpression struct {
@@ -203,6 +339,9 @@ func (pe *PrefixExpression) expressionNode() {}
 //TokenLiteral returns the token literal of the prefix expression
 func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
 
+// Pos returns the position of the prefix expression
+func (pe *PrefixExpression) Pos() token.Position { return pe.Token.Pos }
+
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -240,6 +379,14 @@ func (ie *InfixExpression) expressionNode() {}
 //TokenLiteral returns the token literal of the infix expression
 func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Literal }
 
+// Pos returns the position of the start of the left expression
+func (ie *InfixExpression) Pos() token.Position {
+	if ie.Left != nil {
+		return ie.Left.Pos()
+	}
+	return ie.Token.Pos
+}
+
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -278,6 +425,9 @@ func (b *Boolean) expressionNode() {}
 //TokenLiteral returns the token literal of the boolean
 func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
 
+// Pos returns the position of the boolean
+func (b *Boolean) Pos() token.Position { return b.Token.Pos }
+
 func (b *Boolean) String() string { return b.Token.Literal }
 
 // BlockStatement represents a block of statements. This is usually
@@ -292,6 +442,9 @@ func (bs *BlockStatement) statementNode() {}
 //TokenLiteral returns the token literal of the block statement
 func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
 
+// Pos returns the position of the block statement
+func (bs *BlockStatement) Pos() token.Position { return bs.Token.Pos }
+
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
@@ -319,6 +472,9 @@ func (ie *IfExpression) expressionNode() {}
 //TokenLiteral returns the token literal of the if statement
 func (ie *IfExpression) TokenLiteral() string { return ie.Token.Literal }
 
+// Pos returns the position of the if statement
+func (ie *IfExpression) Pos() token.Position { return ie.Token.Pos }
+
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -357,8 +513,15 @@ func (ie *IfExpression) String() string {
 // var add = func(a, b) { return a + b; }
 type FunctionLiteral struct {
 	Token      token.Token
+	Name       string
 	Parameters []*Identifier
-	Body       *BlockStatement
+	// ParameterTypes holds the type of each parameter, which is nil if it has no annotation
+	ParameterTypes []*Type
+	ReturnType     *Type
+	Body           *BlockStatement
+	// Slots is the number of variables that a call of the function stores in its environment,
+	// which is filled in by the resolver
+	Slots int
 }
 
 func (fl *FunctionLiteral) expressionNode() {}
@@ -366,11 +529,18 @@ func (fl *FunctionLiteral) expressionNode() {}
 //TokenLiteral returns the token literal of the function literal
 func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
 
+// Pos returns the position of the function literal
+func (fl *FunctionLiteral) Pos() token.Position { return fl.Token.Pos }
+
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 
 	params := []string{}
-	for _, p := range fl.Parameters {
+	for i, p := range fl.Parameters {
+		if i < len(fl.ParameterTypes) && fl.ParameterTypes[i] != nil {
+			params = append(params, p.String()+" "+fl.ParameterTypes[i].String())
+			continue
+		}
 		params = append(params, p.String())
 	}
 
@@ -378,6 +548,12 @@ func (fl *FunctionLiteral) String() string {
 	if err != nil {
 		return ""
 	}
+	if fl.Name != "" {
+		_, err = out.WriteString(" " + fl.Name)
+		if err != nil {
+			return ""
+		}
+	}
 	_, err = out.WriteString("(")
 	if err != nil {
 		return ""
@@ -390,6 +566,12 @@ func (fl *FunctionLiteral) String() string {
 	if err != nil {
 		return ""
 	}
+	if fl.ReturnType != nil {
+		_, err = out.WriteString(" " + fl.ReturnType.String())
+		if err != nil {
+			return ""
+		}
+	}
 	_, err = out.WriteString(fl.Body.String())
 	if err != nil {
 		return ""
@@ -398,12 +580,33 @@ func (fl *FunctionLiteral) String() string {
 	return out.String()
 }
 
+// FunctionStatement represents a named function declaration. these are in the form of
+// func add(a, b) { return a + b; }
+type FunctionStatement struct {
+	Token    token.Token
+	Name     *Identifier
+	Function *FunctionLiteral
+}
+
+func (fs *FunctionStatement) statementNode() {}
+
+//TokenLiteral returns the token literal of the function statement
+func (fs *FunctionStatement) TokenLiteral() string { return fs.Token.Literal }
+
+// Pos returns the position of the function statement
+func (fs *FunctionStatement) Pos() token.Position { return fs.Token.Pos }
+
+func (fs *FunctionStatement) String() string { return fs.Function.String() }
+
 // CallExpression represents the call of a function literal. an example of this is
 // add(1, 5 + 2)
 type CallExpression struct {
 	Token     token.Token
 	Function  Expression
 	Arguments []Expression
+	// Tail is true when the call is the last thing that the function around it does, so its
+	// result is the function's result. It is filled in by the evaluator before the program is run
+	Tail bool
 }
 
 func (ce *CallExpression) expressionNode() {}
@@ -411,6 +614,14 @@ func (ce *CallExpression) expressionNode() {}
 //TokenLiteral returns the token literal of the function literal
 func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
 
+// Pos returns the position of the start of the function expression
+func (ce *CallExpression) Pos() token.Position {
+	if ce.Function != nil {
+		return ce.Function.Pos()
+	}
+	return ce.Token.Pos
+}
+
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 
@@ -450,6 +661,9 @@ func (al *ArrayLiteral) expressionNode() {}
 //TokenLiteral returns the token literal of the array literal
 func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
 
+// Pos returns the position of the array literal
+func (al *ArrayLiteral) Pos() token.Position { return al.Token.Pos }
+
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
 
@@ -474,6 +688,35 @@ func (al *ArrayLiteral) String() string {
 	return out.String()
 }
 
+// TupleLiteral represents several values that are returned together from a function, such as
+// the values in 'return a, b'
+type TupleLiteral struct {
+	Token    token.Token
+	Elements []Expression
+}
+
+func (tl *TupleLiteral) expressionNode() {}
+
+//TokenLiteral returns the token literal of the tuple literal
+func (tl *TupleLiteral) TokenLiteral() string { return tl.Token.Literal }
+
+// Pos returns the position of the first value in the tuple
+func (tl *TupleLiteral) Pos() token.Position {
+	if len(tl.Elements) > 0 && tl.Elements[0] != nil {
+		return tl.Elements[0].Pos()
+	}
+	return tl.Token.Pos
+}
+
+func (tl *TupleLiteral) String() string {
+	elements := []string{}
+	for _, e := range tl.Elements {
+		elements = append(elements, e.String())
+	}
+
+	return strings.Join(elements, ", ")
+}
+
 // IndexExpression represents an call to an index of an array
 type IndexExpression struct {
 	Token token.Token
@@ -486,6 +729,14 @@ func (ie *IndexExpression) expressionNode() {}
 //TokenLiteral returns the token literal of the array literal
 func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
 
+// Pos returns the position of the start of the left expression
+func (ie *IndexExpression) Pos() token.Position {
+	if ie.Left != nil {
+		return ie.Left.Pos()
+	}
+	return ie.Token.Pos
+}
+
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 
@@ -524,6 +775,9 @@ func (dl *DictionaryLiteral) expressionNode() {}
 //TokenLiteral returns the token literal of the dictionary literal
 func (dl *DictionaryLiteral) TokenLiteral() string { return dl.Token.Literal }
 
+// Pos returns the position of the dictionary literal
+func (dl *DictionaryLiteral) Pos() token.Position { return dl.Token.Pos }
+
 func (dl *DictionaryLiteral) String() string {
 	var out bytes.Buffer
 
@@ -547,3 +801,223 @@ func (dl *DictionaryLiteral) String() string {
 
 	return out.String()
 }
+
+// WhileStatement represents a while loop. While loops are presented in the form
+// "while (<condition>) {<body>}"
+type WhileStatement struct {
+	Token     token.Token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode() {}
+
+// TokenLiteral returns the token literal of the while loop
+func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+
+// Pos returns the position of the while loop
+func (ws *WhileStatement) Pos() token.Position { return ws.Token.Pos }
+
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+
+	_, err := out.WriteString("while")
+	if err != nil {
+		return ""
+	}
+	_, err = out.WriteString(ws.Condition.String())
+	if err != nil {
+		return ""
+	}
+	_, err = out.WriteString(" ")
+	if err != nil {
+		return ""
+	}
+	_, err = out.WriteString(ws.Body.String())
+	if err != nil {
+		return ""
+	}
+
+	return out.String()
+}
+
+// ForStatement represents a C-style for loop. For loops are presented in the form
+// "for (<init>; <condition>; <post>) {<body>}", where each of the init, condition and post
+// parts can be left out
+type ForStatement struct {
+	Token     token.Token
+	Init      Statement
+	Condition Expression
+	Post      Statement
+	Body      *BlockStatement
+	// Slots is the number of variables stored in the loop's environment, which is filled in
+	// by the resolver
+	Slots int
+}
+
+func (fs *ForStatement) statementNode() {}
+
+// TokenLiteral returns the token literal of the for loop
+func (fs *ForStatement) TokenLiteral() string { return fs.Token.Literal }
+
+// Pos returns the position of the for loop
+func (fs *ForStatement) Pos() token.Position { return fs.Token.Pos }
+
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+
+	parts := []string{"", "", ""}
+	if fs.Init != nil {
+		parts[0] = strings.TrimSuffix(fs.Init.String(), ";")
+	}
+	if fs.Condition != nil {
+		parts[1] = fs.Condition.String()
+	}
+	if fs.Post != nil {
+		parts[2] = strings.TrimSuffix(fs.Post.String(), ";")
+	}
+
+	_, err := out.WriteString("for (")
+	if err != nil {
+		return ""
+	}
+	_, err = out.WriteString(strings.Join(parts, "; "))
+	if err != nil {
+		return ""
+	}
+	_, err = out.WriteString(") ")
+	if err != nil {
+		return ""
+	}
+	_, err = out.WriteString(fs.Body.String())
+	if err != nil {
+		return ""
+	}
+
+	return out.String()
+}
+
+// ForInStatement represents a loop over the elements of an array, the characters of a
+// string or the keys of a dictionary. These are presented in the form
+// "for (<variable> in <iterable>) {<body>}"
+type ForInStatement struct {
+	Token    token.Token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+	// Slots is the number of variables stored in the loop's environment, which is filled in
+	// by the resolver
+	Slots int
+}
+
+func (fi *ForInStatement) statementNode() {}
+
+// TokenLiteral returns the token literal of the for loop
+func (fi *ForInStatement) TokenLiteral() string { return fi.Token.Literal }
+
+// Pos returns the position of the for loop
+func (fi *ForInStatement) Pos() token.Position { return fi.Token.Pos }
+
+func (fi *ForInStatement) String() string {
+	var out bytes.Buffer
+
+	_, err := out.WriteString("for (")
+	if err != nil {
+		return ""
+	}
+	_, err = out.WriteString(fi.Variable.String())
+	if err != nil {
+		return ""
+	}
+	_, err = out.WriteString(" in ")
+	if err != nil {
+		return ""
+	}
+	_, err = out.WriteString(fi.Iterable.String())
+	if err != nil {
+		return ""
+	}
+	_, err = out.WriteString(") ")
+	if err != nil {
+		return ""
+	}
+	_, err = out.WriteString(fi.Body.String())
+	if err != nil {
+		return ""
+	}
+
+	return out.String()
+}
+
+// BreakStatement represents a break statement, which stops the loop it is in
+type BreakStatement struct {
+	Token token.Token
+}
+
+func (bs *BreakStatement) statementNode() {}
+
+// TokenLiteral returns the token literal of the break statement
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+
+// Pos returns the position of the break statement
+func (bs *BreakStatement) Pos() token.Position { return bs.Token.Pos }
+
+func (bs *BreakStatement) String() string { return bs.Token.Literal + ";" }
+
+// ContinueStatement represents a continue statement, which skips to the next
+// iteration of the loop it is in
+type ContinueStatement struct {
+	Token token.Token
+}
+
+func (cs *ContinueStatement) statementNode() {}
+
+// TokenLiteral returns the token literal of the continue statement
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+
+// Pos returns the position of the continue statement
+func (cs *ContinueStatement) Pos() token.Position { return cs.Token.Pos }
+
+func (cs *ContinueStatement) String() string { return cs.Token.Literal + ";" }
+
+// AssignExpression represents the assignment of a new value to an existing variable or
+// to an index of an array or dictionary. Examples of these include 'x = 5', 'x += 1' and
+// 'dict["key"] = value'
+type AssignExpression struct {
+	Token    token.Token
+	Target   Expression
+	Operator string
+	Value    Expression
+}
+
+func (ae *AssignExpression) expressionNode() {}
+
+// TokenLiteral returns the token literal of the assignment
+func (ae *AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+
+// Pos returns the position of the start of the target expression
+func (ae *AssignExpression) Pos() token.Position {
+	if ae.Target != nil {
+		return ae.Target.Pos()
+	}
+	return ae.Token.Pos
+}
+
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	_, err := out.WriteString(ae.Target.String())
+	if err != nil {
+		return ""
+	}
+	_, err = out.WriteString(" " + ae.Operator + " ")
+	if err != nil {
+		return ""
+	}
+	_, err = out.WriteString(ae.Value.String())
+	if err != nil {
+		return ""
+	}
+
+	return out.String()
+}
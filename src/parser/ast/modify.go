@@ -0,0 +1,162 @@
+package ast
+
+import "fmt"
+
+// ModifierFunc is called by Modify for each node of an AST. The node it returns replaces the
+// node that it was given, so returning the node unchanged leaves it where it is
+type ModifierFunc func(Node) Node
+
+// Modify rewrites an AST from the bottom up. The children of each node are modified before
+// the node itself is passed to the modifier, and the result of the modifier replaces the node
+// in its parent. A node can only be replaced by one that fits in the same place, so an
+// expression must be replaced by an expression and a statement by a statement
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch n := node.(type) {
+	case *Program:
+		n.Statements = modifyStatements(n.Statements, modifier)
+	case *VarStatement:
+		n.Name = modifyIdentifier(n.Name, modifier)
+		for i, name := range n.Names {
+			n.Names[i] = modifyIdentifier(name, modifier)
+		}
+		n.Type = modifyType(n.Type, modifier)
+		n.Value = modifyExpression(n.Value, modifier)
+	case *ReturnStatement:
+		n.ReturnValue = modifyExpression(n.ReturnValue, modifier)
+	case *ExpressionStatement:
+		n.Expression = modifyExpression(n.Expression, modifier)
+	case *BlockStatement:
+		n.Statements = modifyStatements(n.Statements, modifier)
+	case *FunctionStatement:
+		n.Name = modifyIdentifier(n.Name, modifier)
+		if n.Function != nil {
+			n.Function = modifyExpression(n.Function, modifier).(*FunctionLiteral)
+		}
+	case *WhileStatement:
+		n.Condition = modifyExpression(n.Condition, modifier)
+		n.Body = modifyBlock(n.Body, modifier)
+	case *ForStatement:
+		n.Init = modifyStatement(n.Init, modifier)
+		n.Condition = modifyExpression(n.Condition, modifier)
+		n.Post = modifyStatement(n.Post, modifier)
+		n.Body = modifyBlock(n.Body, modifier)
+	case *ForInStatement:
+		n.Variable = modifyIdentifier(n.Variable, modifier)
+		n.Iterable = modifyExpression(n.Iterable, modifier)
+		n.Body = modifyBlock(n.Body, modifier)
+	case *PrefixExpression:
+		n.Right = modifyExpression(n.Right, modifier)
+	case *InfixExpression:
+		n.Left = modifyExpression(n.Left, modifier)
+		n.Right = modifyExpression(n.Right, modifier)
+	case *AssignExpression:
+		n.Target = modifyExpression(n.Target, modifier)
+		n.Value = modifyExpression(n.Value, modifier)
+	case *IfExpression:
+		n.Condition = modifyExpression(n.Condition, modifier)
+		n.Consequence = modifyBlock(n.Consequence, modifier)
+		n.Alternative = modifyBlock(n.Alternative, modifier)
+	case *FunctionLiteral:
+		for i, param := range n.Parameters {
+			n.Parameters[i] = modifyIdentifier(param, modifier)
+		}
+		for i, paramType := range n.ParameterTypes {
+			n.ParameterTypes[i] = modifyType(paramType, modifier)
+		}
+		n.ReturnType = modifyType(n.ReturnType, modifier)
+		n.Body = modifyBlock(n.Body, modifier)
+	case *CallExpression:
+		n.Function = modifyExpression(n.Function, modifier)
+		n.Arguments = modifyExpressions(n.Arguments, modifier)
+	case *ArrayLiteral:
+		n.Elements = modifyExpressions(n.Elements, modifier)
+	case *TupleLiteral:
+		n.Elements = modifyExpressions(n.Elements, modifier)
+	case *IndexExpression:
+		n.Left = modifyExpression(n.Left, modifier)
+		n.Index = modifyExpression(n.Index, modifier)
+	case *DictionaryLiteral:
+		pairs := make(map[Expression]Expression, len(n.Pairs))
+		for _, key := range SortedKeys(n) {
+			value := modifyExpression(n.Pairs[key], modifier)
+			pairs[modifyExpression(key, modifier)] = value
+		}
+		n.Pairs = pairs
+	}
+
+	return modifier(node)
+}
+
+func modifyStatements(statements []Statement, modifier ModifierFunc) []Statement {
+	for i, statement := range statements {
+		statements[i] = modifyStatement(statement, modifier)
+	}
+	return statements
+}
+
+func modifyExpressions(expressions []Expression, modifier ModifierFunc) []Expression {
+	for i, exp := range expressions {
+		expressions[i] = modifyExpression(exp, modifier)
+	}
+	return expressions
+}
+
+func modifyStatement(statement Statement, modifier ModifierFunc) Statement {
+	if statement == nil {
+		return nil
+	}
+
+	modified, ok := Modify(statement, modifier).(Statement)
+	if !ok {
+		panic(fmt.Sprintf("ast.Modify: statement %q replaced by a node that isn't a statement", statement))
+	}
+	return modified
+}
+
+func modifyExpression(exp Expression, modifier ModifierFunc) Expression {
+	if exp == nil {
+		return nil
+	}
+
+	modified, ok := Modify(exp, modifier).(Expression)
+	if !ok {
+		panic(fmt.Sprintf("ast.Modify: expression %q replaced by a node that isn't an expression", exp))
+	}
+	return modified
+}
+
+func modifyBlock(block *BlockStatement, modifier ModifierFunc) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+
+	modified, ok := Modify(block, modifier).(*BlockStatement)
+	if !ok {
+		panic(fmt.Sprintf("ast.Modify: block %q replaced by a node that isn't a block", block))
+	}
+	return modified
+}
+
+func modifyIdentifier(ident *Identifier, modifier ModifierFunc) *Identifier {
+	if ident == nil {
+		return nil
+	}
+
+	modified, ok := Modify(ident, modifier).(*Identifier)
+	if !ok {
+		panic(fmt.Sprintf("ast.Modify: identifier %q replaced by a node that isn't an identifier", ident))
+	}
+	return modified
+}
+
+func modifyType(t *Type, modifier ModifierFunc) *Type {
+	if t == nil {
+		return nil
+	}
+
+	modified, ok := Modify(t, modifier).(*Type)
+	if !ok {
+		panic(fmt.Sprintf("ast.Modify: type %q replaced by a node that isn't a type", t))
+	}
+	return modified
+}
@@ -0,0 +1,157 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/kai119/Brisk/src/lexer/token"
+)
+
+func TestModify(t *testing.T) {
+	one := func() Expression { return integer(1, 0) }
+
+	turnOneIntoTwo := func(node Node) Node {
+		integer, ok := node.(*IntegerLiteral)
+		if !ok || integer.Value != 1 {
+			return node
+		}
+
+		integer.Value = 2
+		integer.Token.Literal = "2"
+		return integer
+	}
+
+	tests := []struct {
+		input    Node
+		expected string
+	}{
+		{one(), "2"},
+		{
+			&Program{Statements: []Statement{expression(one())}},
+			"2",
+		},
+		{
+			&InfixExpression{Token: token.Token{Type: token.PLUS, Literal: "+"}, Left: one(), Operator: "+", Right: integer(3, 4)},
+			"(2 + 3)",
+		},
+		{
+			&PrefixExpression{Token: token.Token{Type: token.MINUS, Literal: "-"}, Operator: "-", Right: one()},
+			"(-2)",
+		},
+		{
+			&IndexExpression{Token: token.Token{Type: token.LEFT_SQUARE_BRACKET, Literal: "["}, Left: ident("arr", 0), Index: one()},
+			"(arr[2])",
+		},
+		{
+			&IfExpression{
+				Token:       token.Token{Type: token.COMMAND_IF, Literal: "if"},
+				Condition:   one(),
+				Consequence: block(expression(one())),
+				Alternative: block(expression(one())),
+			},
+			"if2 2else2",
+		},
+		{
+			&IfExpression{
+				Token:       token.Token{Type: token.COMMAND_IF, Literal: "if"},
+				Condition:   one(),
+				Consequence: block(expression(one())),
+			},
+			"if2 2",
+		},
+		{
+			&FunctionLiteral{
+				Token:      token.Token{Type: token.FUNCTION, Literal: "func"},
+				Parameters: []*Identifier{ident("a", 5)},
+				Body: block(&ReturnStatement{
+					Token:       token.Token{Type: token.FUNCTION_RETURN, Literal: "return"},
+					ReturnValue: one(),
+				}),
+			},
+			"func(a)return 2;",
+		},
+		{
+			&ArrayLiteral{Token: token.Token{Type: token.LEFT_SQUARE_BRACKET, Literal: "["}, Elements: []Expression{one(), one()}},
+			"[2, 2]",
+		},
+		{
+			&VarStatement{Token: token.Token{Type: token.VAR_DECLARATION, Literal: "var"}, Name: ident("x", 4), Value: one()},
+			"var x = 2;",
+		},
+		{
+			dictionary(str("a", 1), one()),
+			"{a: 2}",
+		},
+	}
+
+	for _, tt := range tests {
+		modified := Modify(tt.input, turnOneIntoTwo)
+
+		if modified.String() != tt.expected {
+			t.Errorf("wrong result from Modify. expected=%q, got=%q", tt.expected, modified.String())
+		}
+	}
+}
+
+func TestModifyDictionaryKeys(t *testing.T) {
+	dict := dictionary(integer(1, 1), integer(1, 4), integer(3, 7), integer(4, 10))
+
+	Modify(dict, func(node Node) Node {
+		if integer, ok := node.(*IntegerLiteral); ok {
+			integer.Value *= 10
+		}
+		return node
+	})
+
+	if len(dict.Pairs) != 2 {
+		t.Fatalf("dict.Pairs has wrong length. got=%d", len(dict.Pairs))
+	}
+
+	expected := map[int64]int64{10: 10, 30: 40}
+	for key, value := range dict.Pairs {
+		k := key.(*IntegerLiteral).Value
+		v := value.(*IntegerLiteral).Value
+		if expected[k] != v {
+			t.Errorf("wrong value for key %d. expected=%d, got=%d", k, expected[k], v)
+		}
+	}
+}
+
+func TestModifyReplacesNodes(t *testing.T) {
+	// func(a) { a + b }
+	function := &FunctionLiteral{
+		Token:      token.Token{Type: token.FUNCTION, Literal: "func"},
+		Parameters: []*Identifier{ident("a", 5)},
+		Body: block(expression(&InfixExpression{
+			Token:    token.Token{Type: token.PLUS, Literal: "+"},
+			Left:     ident("a", 10),
+			Operator: "+",
+			Right:    ident("b", 14),
+		})),
+	}
+
+	modified := Modify(function, func(node Node) Node {
+		if ident, ok := node.(*Identifier); ok && ident.Value == "b" {
+			return integer(5, ident.Pos().Offset)
+		}
+		return node
+	})
+
+	if modified.String() != "func(a)(a + 5)" {
+		t.Errorf("wrong result from Modify. got=%q", modified.String())
+	}
+}
+
+func TestModifyWrongNodeKind(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected Modify to panic when an expression is replaced by a statement")
+		}
+	}()
+
+	Modify(&Program{Statements: []Statement{expression(integer(1, 0))}}, func(node Node) Node {
+		if integer, ok := node.(*IntegerLiteral); ok {
+			return expression(integer)
+		}
+		return node
+	})
+}
@@ -0,0 +1,161 @@
+package ast
+
+import (
+	"sort"
+)
+
+// Visitor is used by Walk to visit the nodes of an AST. Visit is called for each node that
+// Walk finds. If the visitor it returns is not nil, Walk visits each of the node's children
+// with that visitor, followed by a call of Visit(nil)
+type Visitor interface {
+	Visit(node Node) (w Visitor)
+}
+
+// Walk traverses an AST in depth-first order. It starts by calling v.Visit(node), and then
+// walks each of the node's children with the visitor that was returned, if it isn't nil
+func Walk(v Visitor, node Node) {
+	if v = v.Visit(node); v == nil {
+		return
+	}
+
+	switch n := node.(type) {
+	case *Program:
+		walkStatements(v, n.Statements)
+	case *VarStatement:
+		if n.Name != nil {
+			Walk(v, n.Name)
+		}
+		for _, name := range n.Names {
+			Walk(v, name)
+		}
+		if n.Type != nil {
+			Walk(v, n.Type)
+		}
+		walkExpression(v, n.Value)
+	case *ReturnStatement:
+		walkExpression(v, n.ReturnValue)
+	case *ExpressionStatement:
+		walkExpression(v, n.Expression)
+	case *BlockStatement:
+		walkStatements(v, n.Statements)
+	case *FunctionStatement:
+		Walk(v, n.Name)
+		Walk(v, n.Function)
+	case *WhileStatement:
+		walkExpression(v, n.Condition)
+		walkBlock(v, n.Body)
+	case *ForStatement:
+		if n.Init != nil {
+			Walk(v, n.Init)
+		}
+		walkExpression(v, n.Condition)
+		if n.Post != nil {
+			Walk(v, n.Post)
+		}
+		walkBlock(v, n.Body)
+	case *ForInStatement:
+		Walk(v, n.Variable)
+		walkExpression(v, n.Iterable)
+		walkBlock(v, n.Body)
+	case *PrefixExpression:
+		walkExpression(v, n.Right)
+	case *InfixExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Right)
+	case *AssignExpression:
+		walkExpression(v, n.Target)
+		walkExpression(v, n.Value)
+	case *IfExpression:
+		walkExpression(v, n.Condition)
+		walkBlock(v, n.Consequence)
+		walkBlock(v, n.Alternative)
+	case *FunctionLiteral:
+		for i, param := range n.Parameters {
+			Walk(v, param)
+			if i < len(n.ParameterTypes) && n.ParameterTypes[i] != nil {
+				Walk(v, n.ParameterTypes[i])
+			}
+		}
+		if n.ReturnType != nil {
+			Walk(v, n.ReturnType)
+		}
+		walkBlock(v, n.Body)
+	case *CallExpression:
+		walkExpression(v, n.Function)
+		walkExpressions(v, n.Arguments)
+	case *ArrayLiteral:
+		walkExpressions(v, n.Elements)
+	case *TupleLiteral:
+		walkExpressions(v, n.Elements)
+	case *IndexExpression:
+		walkExpression(v, n.Left)
+		walkExpression(v, n.Index)
+	case *DictionaryLiteral:
+		for _, key := range SortedKeys(n) {
+			walkExpression(v, key)
+			walkExpression(v, n.Pairs[key])
+		}
+	}
+
+	v.Visit(nil)
+}
+
+func walkStatements(v Visitor, statements []Statement) {
+	for _, statement := range statements {
+		if statement != nil {
+			Walk(v, statement)
+		}
+	}
+}
+
+func walkExpressions(v Visitor, expressions []Expression) {
+	for _, exp := range expressions {
+		walkExpression(v, exp)
+	}
+}
+
+func walkExpression(v Visitor, exp Expression) {
+	if exp != nil {
+		Walk(v, exp)
+	}
+}
+
+func walkBlock(v Visitor, block *BlockStatement) {
+	if block != nil {
+		Walk(v, block)
+	}
+}
+
+type inspector func(Node) bool
+
+func (f inspector) Visit(node Node) Visitor {
+	if f(node) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an AST in depth-first order, calling f(node) for each node it finds. If f
+// returns true, Inspect carries on into the node's children, followed by a call of f(nil)
+func Inspect(node Node, f func(Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// SortedKeys returns the keys of a dictionary literal in the order that they appear in the
+// source code, so that dictionaries are always traversed in the same order
+func SortedKeys(dict *DictionaryLiteral) []Expression {
+	keys := make([]Expression, 0, len(dict.Pairs))
+	for key := range dict.Pairs {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i].Pos(), keys[j].Pos()
+		if a.Offset != b.Offset {
+			return a.Offset < b.Offset
+		}
+		return keys[i].String() < keys[j].String()
+	})
+
+	return keys
+}
@@ -0,0 +1,201 @@
+package ast
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/kai119/Brisk/src/lexer/token"
+)
+
+func TestWalk(t *testing.T) {
+	tests := []struct {
+		node     Node
+		expected []string
+	}{
+		{
+			// if (x) { 1 } else { 2 }
+			&IfExpression{
+				Token:       token.Token{Type: token.COMMAND_IF, Literal: "if"},
+				Condition:   ident("x", 4),
+				Consequence: block(expression(integer(1, 9))),
+				Alternative: block(expression(integer(2, 20))),
+			},
+			[]string{
+				"*ast.IfExpression", "*ast.Identifier",
+				"*ast.BlockStatement", "*ast.ExpressionStatement", "*ast.IntegerLiteral",
+				"*ast.BlockStatement", "*ast.ExpressionStatement", "*ast.IntegerLiteral",
+			},
+		},
+		{
+			// if (x) { 1 }
+			&IfExpression{
+				Token:       token.Token{Type: token.COMMAND_IF, Literal: "if"},
+				Condition:   ident("x", 4),
+				Consequence: block(expression(integer(1, 9))),
+			},
+			[]string{
+				"*ast.IfExpression", "*ast.Identifier",
+				"*ast.BlockStatement", "*ast.ExpressionStatement", "*ast.IntegerLiteral",
+			},
+		},
+		{
+			// func(a int, b) int { return a; }
+			&FunctionLiteral{
+				Token:          token.Token{Type: token.FUNCTION, Literal: "func"},
+				Parameters:     []*Identifier{ident("a", 5), ident("b", 12)},
+				ParameterTypes: []*Type{typ("int", 7), nil},
+				ReturnType:     typ("int", 15),
+				Body: block(&ReturnStatement{
+					Token:       token.Token{Type: token.FUNCTION_RETURN, Literal: "return"},
+					ReturnValue: ident("a", 28),
+				}),
+			},
+			[]string{
+				"*ast.FunctionLiteral", "*ast.Identifier", "*ast.Type", "*ast.Identifier", "*ast.Type",
+				"*ast.BlockStatement", "*ast.ReturnStatement", "*ast.Identifier",
+			},
+		},
+		{
+			// {"b": 2, "a": 1}
+			dictionary(str("b", 1), integer(2, 6), str("a", 9), integer(1, 14)),
+			[]string{
+				"*ast.DictionaryLiteral",
+				"*ast.StringLiteral", "*ast.IntegerLiteral",
+				"*ast.StringLiteral", "*ast.IntegerLiteral",
+			},
+		},
+		{
+			// arr[i + 1]
+			&IndexExpression{
+				Token: token.Token{Type: token.LEFT_SQUARE_BRACKET, Literal: "["},
+				Left:  ident("arr", 0),
+				Index: &InfixExpression{
+					Token:    token.Token{Type: token.PLUS, Literal: "+"},
+					Left:     ident("i", 4),
+					Operator: "+",
+					Right:    integer(1, 8),
+				},
+			},
+			[]string{
+				"*ast.IndexExpression", "*ast.Identifier",
+				"*ast.InfixExpression", "*ast.Identifier", "*ast.IntegerLiteral",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		var visited []string
+		Inspect(tt.node, func(node Node) bool {
+			if node != nil {
+				visited = append(visited, reflect.TypeOf(node).String())
+			}
+			return true
+		})
+
+		if !reflect.DeepEqual(visited, tt.expected) {
+			t.Errorf("wrong nodes visited for %q. expected=%q, got=%q", tt.node.String(), tt.expected, visited)
+		}
+	}
+}
+
+func TestWalkDictionaryOrder(t *testing.T) {
+	dict := dictionary(str("c", 1), integer(3, 6), str("a", 9), integer(1, 14), str("b", 17), integer(2, 22))
+
+	for i := 0; i < 10; i++ {
+		var values []string
+		Inspect(dict, func(node Node) bool {
+			if node != nil && node != Node(dict) {
+				values = append(values, node.String())
+			}
+			return true
+		})
+
+		expected := []string{"c", "3", "a", "1", "b", "2"}
+		if !reflect.DeepEqual(values, expected) {
+			t.Fatalf("dictionary not walked in source order. expected=%q, got=%q", expected, values)
+		}
+	}
+}
+
+func TestInspectSkipsChildren(t *testing.T) {
+	// func(a) { a } (1)
+	call := &CallExpression{
+		Token: token.Token{Type: token.LEFT_BRACKET, Literal: "("},
+		Function: &FunctionLiteral{
+			Token:      token.Token{Type: token.FUNCTION, Literal: "func"},
+			Parameters: []*Identifier{ident("a", 5)},
+			Body:       block(expression(ident("a", 10))),
+		},
+		Arguments: []Expression{integer(1, 15)},
+	}
+
+	var visited []string
+	Inspect(call, func(node Node) bool {
+		if node == nil {
+			return false
+		}
+		visited = append(visited, reflect.TypeOf(node).String())
+		_, isFunction := node.(*FunctionLiteral)
+		return !isFunction
+	})
+
+	expected := []string{"*ast.CallExpression", "*ast.FunctionLiteral", "*ast.IntegerLiteral"}
+	if !reflect.DeepEqual(visited, expected) {
+		t.Errorf("wrong nodes visited. expected=%q, got=%q", expected, visited)
+	}
+}
+
+func ident(name string, offset int) *Identifier {
+	return &Identifier{
+		Token: token.Token{Type: token.IDENT, Literal: name, Pos: pos(offset)},
+		Value: name,
+	}
+}
+
+func typ(name string, offset int) *Type {
+	return &Type{
+		Token: token.Token{Type: token.IDENT, Literal: name, Pos: pos(offset)},
+		Name:  name,
+	}
+}
+
+func integer(value int64, offset int) *IntegerLiteral {
+	return &IntegerLiteral{
+		Token: token.Token{Type: token.INT, Literal: strconv.FormatInt(value, 10), Pos: pos(offset)},
+		Value: value,
+	}
+}
+
+func str(value string, offset int) *StringLiteral {
+	return &StringLiteral{
+		Token: token.Token{Type: token.STRING, Literal: value, Pos: pos(offset)},
+		Value: value,
+	}
+}
+
+func expression(exp Expression) *ExpressionStatement {
+	return &ExpressionStatement{Token: token.Token{Literal: exp.TokenLiteral(), Pos: exp.Pos()}, Expression: exp}
+}
+
+func block(statements ...Statement) *BlockStatement {
+	return &BlockStatement{
+		Token:      token.Token{Type: token.LEFT_CURLY_BRACKET, Literal: "{"},
+		Statements: statements,
+	}
+}
+
+func dictionary(pairs ...Expression) *DictionaryLiteral {
+	dict := &DictionaryLiteral{
+		Token: token.Token{Type: token.LEFT_CURLY_BRACKET, Literal: "{"},
+		Pairs: map[Expression]Expression{},
+	}
+	for i := 0; i < len(pairs); i += 2 {
+		dict.Pairs[pairs[i]] = pairs[i+1]
+	}
+	return dict
+}
+
+func pos(offset int) token.Position {
+	return token.Position{Line: 1, Column: offset + 1, Offset: offset}
+}
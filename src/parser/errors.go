@@ -0,0 +1,20 @@
+package parser
+
+import (
+	"fmt"
+
+	"github.com/kai119/Brisk/src/lexer/token"
+)
+
+// ParseError is a syntax error found by the parser. Errors caused by the parser expecting a
+// different token also hold the type of token that was expected and the type that was found
+type ParseError struct {
+	Pos      token.Position
+	Expected token.Type
+	Found    token.Type
+	Message  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Pos, e.Message)
+}
@@ -3,6 +3,8 @@ package parser
 import (
 	"fmt"
 	"strconv"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/kai119/Brisk/src/lexer"
 	"github.com/kai119/Brisk/src/lexer/token"
@@ -17,6 +19,9 @@ type (
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN
+	OR
+	AND
 	EQUALS
 	LESSGREATER
 	SUM
@@ -27,16 +32,27 @@ const (
 )
 
 var precedences = map[token.Type]int{
-	token.CONDITION_EQUALS:    EQUALS,
-	token.CONDITION_NOT_EQUAL: EQUALS,
-	token.CONDITION_LESS_THAN: LESSGREATER,
-	token.CONDITION_MORE_THAN: LESSGREATER,
-	token.PLUS:                SUM,
-	token.MINUS:               SUM,
-	token.MULTIPLY:            PRODUCT,
-	token.DIVIDE:              PRODUCT,
-	token.LEFT_BRACKET:        CALL,
-	token.LEFT_SQUARE_BRACKET: INDEX,
+	token.VAR_EQUALS:                ASSIGN,
+	token.PLUS_EQUALS:               ASSIGN,
+	token.MINUS_EQUALS:              ASSIGN,
+	token.MULTIPLY_EQUALS:           ASSIGN,
+	token.DIVIDE_EQUALS:             ASSIGN,
+	token.MOD_EQUALS:                ASSIGN,
+	token.LOGICAL_OR:                OR,
+	token.LOGICAL_AND:               AND,
+	token.CONDITION_EQUALS:          EQUALS,
+	token.CONDITION_NOT_EQUAL:       EQUALS,
+	token.CONDITION_LESS_THAN:       LESSGREATER,
+	token.CONDITION_MORE_THAN:       LESSGREATER,
+	token.CONDITION_LESS_THAN_EQUAL: LESSGREATER,
+	token.CONDITION_MORE_THAN_EQUAL: LESSGREATER,
+	token.PLUS:                      SUM,
+	token.MINUS:                     SUM,
+	token.MULTIPLY:                  PRODUCT,
+	token.DIVIDE:                    PRODUCT,
+	token.MOD:                       PRODUCT,
+	token.LEFT_BRACKET:              CALL,
+	token.LEFT_SQUARE_BRACKET:       INDEX,
 }
 
 // Parser represents the structure of a parser. It contains the lexer that the parser
@@ -44,21 +60,33 @@ var precedences = map[token.Type]int{
 // the next token in the lexer
 type Parser struct {
 	l         *lexer.Lexer
-	errors    []string
+	errors    []*ParseError
 	curToken  token.Token
 	peekToken token.Token
 
+	// panicking is set after a syntax error until the parser has skipped to the start of the
+	// next statement. Errors found while panicking are caused by the first one, so they
+	// aren't reported
+	panicking bool
+
+	// loopDepth is the number of loops around the current token, used to check
+	// that break and continue statements are only used inside of loops
+	loopDepth int
+
 	prefixParseFns map[token.Type]prefixParseFn
 	infixParseFns  map[token.Type]infixParseFn
 }
 
 // New creates a new parser that contains the specified lexer
 func New(l *lexer.Lexer) *Parser {
-	p := &Parser{l: l, errors: []string{}}
+	p := &Parser{l: l, errors: []*ParseError{}}
 	p.prefixParseFns = make(map[token.Type]prefixParseFn)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.CHAR, p.parseCharLiteral)
 	p.registerPrefix(token.CONDITION_NOT, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.BOOL_TRUE, p.parseBoolean)
@@ -74,10 +102,21 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.DIVIDE, p.parseInfixExpression)
 	p.registerInfix(token.MULTIPLY, p.parseInfixExpression)
+	p.registerInfix(token.MOD, p.parseInfixExpression)
+	p.registerInfix(token.LOGICAL_AND, p.parseInfixExpression)
+	p.registerInfix(token.LOGICAL_OR, p.parseInfixExpression)
 	p.registerInfix(token.CONDITION_EQUALS, p.parseInfixExpression)
 	p.registerInfix(token.CONDITION_NOT_EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.CONDITION_MORE_THAN, p.parseInfixExpression)
 	p.registerInfix(token.CONDITION_LESS_THAN, p.parseInfixExpression)
+	p.registerInfix(token.CONDITION_MORE_THAN_EQUAL, p.parseInfixExpression)
+	p.registerInfix(token.CONDITION_LESS_THAN_EQUAL, p.parseInfixExpression)
+	p.registerInfix(token.VAR_EQUALS, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_EQUALS, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_EQUALS, p.parseAssignExpression)
+	p.registerInfix(token.MULTIPLY_EQUALS, p.parseAssignExpression)
+	p.registerInfix(token.DIVIDE_EQUALS, p.parseAssignExpression)
+	p.registerInfix(token.MOD_EQUALS, p.parseAssignExpression)
 	p.registerInfix(token.LEFT_BRACKET, p.parseCallExpression)
 	p.registerInfix(token.LEFT_SQUARE_BRACKET, p.parseIndexExpression)
 
@@ -108,9 +147,12 @@ func (p *Parser) ParseProgram() *ast.Program {
 
 	for p.curToken.Type != token.EOF {
 		stmt := p.parseStatement()
-		if stmt != nil {
+		if stmt != nil && !p.panicking {
 			program.Statements = append(program.Statements, stmt)
 		}
+		if p.panicking {
+			p.synchronize()
+		}
 		p.nextToken()
 	}
 
@@ -118,13 +160,72 @@ func (p *Parser) ParseProgram() *ast.Program {
 }
 
 // Errors returns all errors that the parser has encountered
-func (p *Parser) Errors() []string {
+func (p *Parser) Errors() []*ParseError {
 	return p.errors
 }
 
+func (p *Parser) addError(pos token.Position, msg string) {
+	p.addParseError(&ParseError{Pos: pos, Found: p.curToken.Type, Message: msg})
+}
+
+// addParseError records a syntax error and puts the parser into panic mode. Errors found
+// while the parser is already panicking, or at the same position as the last error, are
+// follow-on errors and are dropped
+func (p *Parser) addParseError(err *ParseError) {
+	if p.panicking {
+		return
+	}
+
+	if len(p.errors) > 0 && p.errors[len(p.errors)-1].Pos == err.Pos {
+		return
+	}
+
+	p.errors = append(p.errors, err)
+	p.panicking = true
+}
+
 func (p *Parser) peekError(t token.Type) {
 	msg := fmt.Sprintf("expected next token to be %s, got %s instead", t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.addParseError(&ParseError{Pos: p.peekToken.Pos, Expected: t, Found: p.peekToken.Type, Message: msg})
+}
+
+// synchronize recovers from a syntax error by skipping the rest of the broken statement. It
+// stops at the end of the statement, or just before a closing bracket or a keyword that starts
+// a new statement, so that parsing can carry on from there. Any blocks opened while skipping
+// are skipped as a whole, so that the statements inside of them aren't parsed on their own
+func (p *Parser) synchronize() {
+	depth := 0
+
+	for !p.peekTokenIs(token.EOF) {
+		if depth == 0 && (p.curTokenIs(token.END_OF_LINE) ||
+			p.peekTokenIs(token.RIGHT_CURLY_BRACKET) ||
+			isStatementKeyword(p.peekToken.Type)) {
+			break
+		}
+
+		p.nextToken()
+
+		switch p.curToken.Type {
+		case token.LEFT_CURLY_BRACKET:
+			depth++
+		case token.RIGHT_CURLY_BRACKET:
+			if depth > 0 {
+				depth--
+			}
+		}
+	}
+
+	p.panicking = false
+}
+
+func isStatementKeyword(t token.Type) bool {
+	switch t {
+	case token.VAR_DECLARATION, token.FUNCTION_RETURN, token.COMMAND_IF, token.COMMAND_WHILE,
+		token.COMMAND_FOR, token.COMMAND_BREAK, token.COMMAND_CONTINUE:
+		return true
+	default:
+		return false
+	}
 }
 
 func (p *Parser) parseStatement() ast.Statement {
@@ -133,6 +234,17 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseVarStatement()
 	case token.FUNCTION_RETURN:
 		return p.parseReturnStatement()
+	case token.COMMAND_WHILE:
+		return p.parseWhileStatement()
+	case token.COMMAND_FOR:
+		return p.parseForStatement()
+	case token.COMMAND_BREAK, token.COMMAND_CONTINUE:
+		return p.parseLoopControlStatement()
+	case token.FUNCTION:
+		if p.peekTokenIs(token.IDENT) {
+			return p.parseFunctionStatement()
+		}
+		return p.parseExpressionStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -141,11 +253,48 @@ func (p *Parser) parseStatement() ast.Statement {
 func (p *Parser) parseVarStatement() *ast.VarStatement {
 	stmt := &ast.VarStatement{Token: p.curToken}
 
-	if !p.expectPeek(token.IDENT) {
-		return nil
-	}
+	switch {
+	case p.peekTokenIs(token.LEFT_SQUARE_BRACKET):
+		p.nextToken()
+		stmt.Destructure = ast.ArrayDestructure
+		stmt.Names = p.parseDestructureNames(token.RIGHT_SQUARE_BRACKET)
+		if stmt.Names == nil {
+			return nil
+		}
+	case p.peekTokenIs(token.LEFT_CURLY_BRACKET):
+		p.nextToken()
+		stmt.Destructure = ast.DictionaryDestructure
+		stmt.Names = p.parseDestructureNames(token.RIGHT_CURLY_BRACKET)
+		if stmt.Names == nil {
+			return nil
+		}
+	default:
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
 
-	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+		if p.peekTokenIs(token.COMMA) {
+			stmt.Destructure = ast.TupleDestructure
+			stmt.Names = []*ast.Identifier{stmt.Name}
+			stmt.Name = nil
+
+			for p.peekTokenIs(token.COMMA) {
+				p.nextToken()
+				if !p.expectPeek(token.IDENT) {
+					return nil
+				}
+				stmt.Names = append(stmt.Names, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+			}
+		} else if p.peekTokenIs(token.IDENT) {
+			p.nextToken()
+			stmt.Type = p.parseType()
+			if stmt.Type == nil {
+				return nil
+			}
+		}
+	}
 
 	if !p.expectPeek(token.VAR_EQUALS) {
 		return nil
@@ -155,13 +304,37 @@ func (p *Parser) parseVarStatement() *ast.VarStatement {
 
 	stmt.Value = p.parseExpression(LOWEST)
 
-	for !p.curTokenIs(token.END_OF_LINE) {
+	if p.peekTokenIs(token.END_OF_LINE) {
 		p.nextToken()
 	}
 
 	return stmt
 }
 
+// parseDestructureNames parses the names of the variables inside of an array or dictionary
+// destructuring pattern, up to the token that closes the pattern
+func (p *Parser) parseDestructureNames(end token.Type) []*ast.Identifier {
+	names := []*ast.Identifier{}
+
+	for {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		names = append(names, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(end) {
+		return nil
+	}
+
+	return names
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.BOOL_TRUE)}
 }
@@ -173,13 +346,150 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 
 	stmt.ReturnValue = p.parseExpression(LOWEST)
 
-	for !p.curTokenIs(token.END_OF_LINE) {
+	if p.peekTokenIs(token.COMMA) {
+		tuple := &ast.TupleLiteral{Token: p.peekToken, Elements: []ast.Expression{stmt.ReturnValue}}
+		for p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+			p.nextToken()
+			tuple.Elements = append(tuple.Elements, p.parseExpression(LOWEST))
+		}
+		stmt.ReturnValue = tuple
+	}
+
+	if p.peekTokenIs(token.END_OF_LINE) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseWhileStatement() ast.Statement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LEFT_BRACKET) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RIGHT_BRACKET) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	if stmt.Body == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+// parseForStatement parses both kinds of for loop, deciding which one it is by checking
+// whether the first identifier inside the brackets is followed by the 'in' keyword
+func (p *Parser) parseForStatement() ast.Statement {
+	tok := p.curToken
+
+	if !p.expectPeek(token.LEFT_BRACKET) {
+		return nil
+	}
+
+	p.nextToken()
+
+	if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.COMMAND_IN) {
+		return p.parseForInStatement(tok)
+	}
+
+	stmt := &ast.ForStatement{Token: tok}
+
+	if !p.curTokenIs(token.END_OF_LINE) {
+		stmt.Init = p.parseStatement()
+		if !p.curTokenIs(token.END_OF_LINE) && !p.expectPeek(token.END_OF_LINE) {
+			return nil
+		}
+	}
+
+	if !p.peekTokenIs(token.END_OF_LINE) {
+		p.nextToken()
+		stmt.Condition = p.parseExpression(LOWEST)
+	}
+
+	if !p.expectPeek(token.END_OF_LINE) {
+		return nil
+	}
+
+	if !p.peekTokenIs(token.RIGHT_BRACKET) {
 		p.nextToken()
+		stmt.Post = &ast.ExpressionStatement{Token: p.curToken, Expression: p.parseExpression(LOWEST)}
+	}
+
+	if !p.expectPeek(token.RIGHT_BRACKET) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	if stmt.Body == nil {
+		return nil
 	}
 
 	return stmt
 }
 
+func (p *Parser) parseForInStatement(tok token.Token) ast.Statement {
+	stmt := &ast.ForInStatement{Token: tok}
+	stmt.Variable = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	p.nextToken()
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RIGHT_BRACKET) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	if stmt.Body == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+// parseLoopBody parses the block of a loop, keeping track of the loop depth so that break
+// and continue statements inside it are allowed
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	if !p.expectPeek(token.LEFT_CURLY_BRACKET) {
+		return nil
+	}
+
+	p.loopDepth++
+	body := p.parseBlockStatement()
+	p.loopDepth--
+
+	if p.peekTokenIs(token.END_OF_LINE) {
+		p.nextToken()
+	}
+
+	return body
+}
+
+func (p *Parser) parseLoopControlStatement() ast.Statement {
+	tok := p.curToken
+
+	if p.loopDepth == 0 {
+		p.addError(tok.Pos, fmt.Sprintf("%s statement outside of loop", tok.Literal))
+	}
+
+	if p.peekTokenIs(token.END_OF_LINE) {
+		p.nextToken()
+	}
+
+	if tok.Type == token.COMMAND_BREAK {
+		return &ast.BreakStatement{Token: tok}
+	}
+	return &ast.ContinueStatement{Token: tok}
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 
@@ -252,6 +562,38 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
+// parseAssignExpression parses an assignment to the expression on its left, which must be
+// either an identifier or an index expression. The value is parsed with the lowest precedence
+// so that assignments are right associative, meaning 'a = b = 5' assigns 5 to both a and b
+func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Target:   left,
+	}
+
+	switch left.(type) {
+	case *ast.Identifier, *ast.IndexExpression:
+	case nil:
+		return nil
+	default:
+		p.addError(left.Pos(), fmt.Sprintf("cannot assign to %s", left.String()))
+		return nil
+	}
+
+	p.nextToken()
+	expression.Value = p.parseExpression(LOWEST)
+
+	return expression
+}
+
+// parseIllegal reports an illegal token found by the lexer. The literal of an illegal token
+// is either the character that couldn't be read or a message explaining what went wrong
+func (p *Parser) parseIllegal() ast.Expression {
+	p.addError(p.curToken.Pos, fmt.Sprintf("illegal token: %s", p.curToken.Literal))
+	return nil
+}
+
 func (p *Parser) parseIdentifier() ast.Expression {
 	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 }
@@ -259,10 +601,50 @@ func (p *Parser) parseIdentifier() ast.Expression {
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
-	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	digits, base := integerDigits(p.curToken.Literal)
+	value, err := strconv.ParseInt(digits, base, 64)
+	if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+		msg := fmt.Sprintf("integer literal %s overflows int64", p.curToken.Literal)
+		p.addError(p.curToken.Pos, msg)
+		return nil
+	}
 	if err != nil {
 		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(p.curToken.Pos, msg)
+		return nil
+	}
+
+	lit.Value = value
+
+	return lit
+}
+
+// integerDigits splits an integer literal into its digits, without the base prefix or any
+// underscores, and the base that they are written in. Literals without a prefix are decimal
+func integerDigits(literal string) (string, int) {
+	digits := strings.Replace(literal, "_", "", -1)
+	if len(digits) < 2 || digits[0] != '0' {
+		return digits, 10
+	}
+
+	switch digits[1] {
+	case 'x', 'X':
+		return digits[2:], 16
+	case 'o', 'O':
+		return digits[2:], 8
+	case 'b', 'B':
+		return digits[2:], 2
+	}
+	return digits, 10
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("could not parse %q as float", p.curToken.Literal)
+		p.addError(p.curToken.Pos, msg)
 		return nil
 	}
 
@@ -275,6 +657,11 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseCharLiteral() ast.Expression {
+	value, _ := utf8.DecodeRuneInString(p.curToken.Literal)
+	return &ast.CharLiteral{Token: p.curToken, Value: value}
+}
+
 func (p *Parser) parseIfExpression() ast.Expression {
 	expression := &ast.IfExpression{Token: p.curToken}
 
@@ -308,49 +695,120 @@ func (p *Parser) parseIfExpression() ast.Expression {
 	return expression
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
+// parseFunctionParameters parses the parameters of a function, along with the type that each
+// parameter is annotated with. The type of a parameter without an annotation is nil
+func (p *Parser) parseFunctionParameters() ([]*ast.Identifier, []*ast.Type) {
 	identifiers := []*ast.Identifier{}
+	types := []*ast.Type{}
 
 	if p.peekTokenIs(token.RIGHT_BRACKET) {
 		p.nextToken()
-		return identifiers
+		return identifiers, types
 	}
 
-	p.nextToken()
-
-	ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	identifiers = append(identifiers, ident)
+	for {
+		if !p.expectPeek(token.IDENT) {
+			return nil, nil
+		}
 
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
-		p.nextToken()
 		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 		identifiers = append(identifiers, ident)
+
+		var paramType *ast.Type
+		if p.peekTokenIs(token.IDENT) {
+			p.nextToken()
+			paramType = p.parseType()
+			if paramType == nil {
+				return nil, nil
+			}
+		}
+		types = append(types, paramType)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
 	}
 
 	if !p.expectPeek(token.RIGHT_BRACKET) {
+		return nil, nil
+	}
+
+	return identifiers, types
+}
+
+// parseType parses the type annotation at the current token, adding an error if it isn't
+// the name of a type
+func (p *Parser) parseType() *ast.Type {
+	if !token.IsType(p.curToken.Literal) {
+		p.addError(p.curToken.Pos, fmt.Sprintf("unknown type: %s", p.curToken.Literal))
 		return nil
 	}
 
-	return identifiers
+	return &ast.Type{Token: p.curToken, Name: p.curToken.Literal}
 }
 
 func (p *Parser) parseFunctionLiteral() ast.Expression {
 	lit := &ast.FunctionLiteral{Token: p.curToken}
 
-	if !p.expectPeek(token.LEFT_BRACKET) {
+	if !p.parseFunction(lit) {
 		return nil
 	}
 
-	lit.Parameters = p.parseFunctionParameters()
+	return lit
+}
 
-	if !p.expectPeek(token.LEFT_CURLY_BRACKET) {
+// parseFunctionStatement parses a named function declaration in the form
+// func name(params) { body }
+func (p *Parser) parseFunctionStatement() ast.Statement {
+	stmt := &ast.FunctionStatement{Token: p.curToken}
+
+	p.nextToken()
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	stmt.Function = &ast.FunctionLiteral{Token: stmt.Token, Name: stmt.Name.Value}
+
+	if !p.parseFunction(stmt.Function) {
 		return nil
 	}
 
+	if p.peekTokenIs(token.END_OF_LINE) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseFunction parses the parameters and body of a function into the function literal,
+// returning false if they couldn't be parsed
+func (p *Parser) parseFunction(lit *ast.FunctionLiteral) bool {
+	if !p.expectPeek(token.LEFT_BRACKET) {
+		return false
+	}
+
+	lit.Parameters, lit.ParameterTypes = p.parseFunctionParameters()
+	if lit.Parameters == nil {
+		return false
+	}
+
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		lit.ReturnType = p.parseType()
+		if lit.ReturnType == nil {
+			return false
+		}
+	}
+
+	if !p.expectPeek(token.LEFT_CURLY_BRACKET) {
+		return false
+	}
+
+	// loops outside of the function can't be broken out of from inside it
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
 	lit.Body = p.parseBlockStatement()
+	p.loopDepth = loopDepth
 
-	return lit
+	return true
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
@@ -367,9 +825,12 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 
 	for !p.curTokenIs(token.RIGHT_CURLY_BRACKET) && !p.curTokenIs(token.EOF) {
 		stmt := p.parseStatement()
-		if stmt != nil {
+		if stmt != nil && !p.panicking {
 			block.Statements = append(block.Statements, stmt)
 		}
+		if p.panicking {
+			p.synchronize()
+		}
 		p.nextToken()
 	}
 
@@ -452,7 +913,7 @@ func (p *Parser) parseDictionaryLiteral() ast.Expression {
 
 func (p *Parser) noPrefixParseFnError(t token.Type) {
 	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+	p.addParseError(&ParseError{Pos: p.curToken.Pos, Found: t, Message: msg})
 }
 
 func (p *Parser) curTokenIs(t token.Type) bool {
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/kai119/Brisk/src/lexer"
+	"github.com/kai119/Brisk/src/lexer/token"
 	"github.com/kai119/Brisk/src/parser/ast"
 )
 
@@ -88,6 +89,74 @@ func TestReturnStatement(t *testing.T) {
 	}
 }
 
+func TestReturnMultipleValues(t *testing.T) {
+	input := "return x, 5, true;"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("number of statements in program is not correct. got=%d", len(program.Statements))
+	}
+
+	tuple, ok := program.Statements[0].(*ast.ReturnStatement).ReturnValue.(*ast.TupleLiteral)
+	if !ok {
+		t.Fatalf("return value is not ast.TupleLiteral. got=%T", program.Statements[0].(*ast.ReturnStatement).ReturnValue)
+	}
+
+	if len(tuple.Elements) != 3 {
+		t.Fatalf("tuple has wrong number of elements. got=%d", len(tuple.Elements))
+	}
+
+	testLiteralExpression(t, tuple.Elements[0], "x")
+	testLiteralExpression(t, tuple.Elements[1], 5)
+	testLiteralExpression(t, tuple.Elements[2], true)
+}
+
+func TestDestructuringVarStatements(t *testing.T) {
+	tests := []struct {
+		input               string
+		expectedDestructure ast.Destructure
+		expectedNames       []string
+		expectedString      string
+	}{
+		{"var x, y = f();", ast.TupleDestructure, []string{"x", "y"}, "var x, y = f();"},
+		{"var [a, b, c] = arr;", ast.ArrayDestructure, []string{"a", "b", "c"}, "var [a, b, c] = arr;"},
+		{"var {name, age} = person;", ast.DictionaryDestructure, []string{"name", "age"}, "var {name, age} = person;"},
+		{"var [only] = [1];", ast.ArrayDestructure, []string{"only"}, "var [only] = [1];"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.VarStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.VarStatement. got=%T", program.Statements[0])
+		}
+
+		if stmt.Destructure != tt.expectedDestructure {
+			t.Errorf("wrong destructure for %q. expected=%d, got=%d", tt.input, tt.expectedDestructure, stmt.Destructure)
+		}
+
+		if len(stmt.Names) != len(tt.expectedNames) {
+			t.Fatalf("wrong number of names for %q. expected=%d, got=%d", tt.input, len(tt.expectedNames), len(stmt.Names))
+		}
+
+		for i, name := range tt.expectedNames {
+			testIdentifier(t, stmt.Names[i], name)
+		}
+
+		if stmt.String() != tt.expectedString {
+			t.Errorf("stmt.String() wrong. expected=%q, got=%q", tt.expectedString, stmt.String())
+		}
+	}
+}
+
 func TestIdentifierExpression(t *testing.T) {
 	input := "test;"
 
@@ -142,6 +211,93 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestIntegerLiteralBases(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0x1F;", 31},
+		{"0XfF;", 255},
+		{"0o17;", 15},
+		{"0b1010;", 10},
+		{"1_000_000;", 1000000},
+		{"0xFF_FF;", 65535},
+		{"9223372036854775807;", 9223372036854775807},
+		{"0x7FFF_FFFF_FFFF_FFFF;", 9223372036854775807},
+		{"0;", 0},
+		{"100;", 100},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+		literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.IntegerLiteral. got=%T", stmt.Expression)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %d. got=%d", tt.expected, literal.Value)
+		}
+	}
+}
+
+func TestIntegerLiteralOverflow(t *testing.T) {
+	input := "9223372036854775808;"
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 parser error, got=%d (%q)", len(errors), errors)
+	}
+
+	if errors[0].Error() != "1:1: integer literal 9223372036854775808 overflows int64" {
+		t.Errorf("wrong error. got=%q", errors[0].Error())
+	}
+}
+
+func TestFloatLiteralExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14;", 3.14},
+		{"1e-9;", 1e-9},
+		{"2.5E+3;", 2500},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("number of statements in program is not correct. got=%d", len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+		}
+		if literal.Value != tt.expected {
+			t.Errorf("literal.Value not %g. got=%g", tt.expected, literal.Value)
+		}
+	}
+}
+
 func TestStringLiteralExpression(t *testing.T) {
 	input := `"hello world"`
 
@@ -163,6 +319,27 @@ func TestStringLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestCharLiteralExpression(t *testing.T) {
+	input := `'é'`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+	}
+	literal, ok := stmt.Expression.(*ast.CharLiteral)
+	if !ok {
+		t.Fatalf("exp not *ast.CharLiteral. got=%T", stmt.Expression)
+	}
+	if literal.Value != 'é' {
+		t.Errorf("literal.Value not %q. got=%q", 'é', literal.Value)
+	}
+}
+
 func TestBooleanExpression(t *testing.T) {
 	input := "true;"
 
@@ -243,6 +420,9 @@ func TestParsingInfixExpressiong(t *testing.T) {
 		{"5 < 5;", 5, "<", 5},
 		{"5 == 5;", 5, "==", 5},
 		{"5 != 5;", 5, "!=", 5},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 >= 5;", 5, ">=", 5},
+		{"5 % 5;", 5, "%", 5},
 		{"true == true", true, "==", true},
 		{"true != false", true, "!=", false},
 		{"false == false", false, "==", false},
@@ -378,6 +558,34 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a + b % c * d",
+			"(a + ((b % c) * d))",
+		},
+		{
+			"a <= b == c >= d",
+			"((a <= b) == (c >= d))",
+		},
+		{
+			"a or b and c",
+			"(a or (b and c))",
+		},
+		{
+			"a && b || c && d",
+			"((a && b) || (c && d))",
+		},
+		{
+			"a == 1 or b != 2",
+			"((a == 1) or (b != 2))",
+		},
+		{
+			"!a and b < c",
+			"((!a) and (b < c))",
+		},
+		{
+			"x = a or b",
+			"x = (a or b)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -393,6 +601,57 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 	}
 }
 
+func TestAssignExpressionParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5;", "x = 5"},
+		{"x += 1 + 2;", "x += (1 + 2)"},
+		{"a = b = c;", "a = b = c"},
+		{"arr[0] *= 2;", "(arr[0]) *= 2"},
+		{`d["key"] = [1];`, "(d[key]) = [1]"},
+		{"x -= y == 1", "x -= (y == 1)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+
+		if _, ok := stmt.Expression.(*ast.AssignExpression); !ok {
+			t.Fatalf("stmt.Expression is not ast.AssignExpression. got=%T", stmt.Expression)
+		}
+
+		if program.String() != tt.expected {
+			t.Errorf("expected %q, got=%q", tt.expected, program.String())
+		}
+	}
+}
+
+func TestInvalidAssignTarget(t *testing.T) {
+	input := "5 = x;"
+
+	l := lexer.New(input)
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 parser error, got=%d (%q)", len(errors), errors)
+	}
+
+	if errors[0].Error() != "1:1: cannot assign to 5" {
+		t.Errorf("wrong error. got=%q", errors[0].Error())
+	}
+}
+
 func TestIfExpression(t *testing.T) {
 	input := `if (x < y) { x }`
 
@@ -486,6 +745,123 @@ func TestIfElseExpression(t *testing.T) {
 	}
 }
 
+func TestWhileStatement(t *testing.T) {
+	input := `while (x < y) { x; break; }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("number of statements in program is not correct. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.WhileStatement. got=%T", program.Statements[0])
+	}
+
+	if !testInfixExpression(t, stmt.Condition, "x", "<", "y") {
+		return
+	}
+
+	if len(stmt.Body.Statements) != 2 {
+		t.Fatalf("body is not 2 statements. got=%d", len(stmt.Body.Statements))
+	}
+
+	if _, ok := stmt.Body.Statements[1].(*ast.BreakStatement); !ok {
+		t.Fatalf("Statements[1] is not ast.BreakStatement. got=%T", stmt.Body.Statements[1])
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"for (var i = 0; i < 10; i) { continue; }", "for (var i = 0; (i < 10); i) continue;"},
+		{"for (;;) { break; }", "for (; ; ) break;"},
+		{"for (i; ; i + 1) { x }", "for (i; ; (i + 1)) x"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("number of statements in program is not correct. got=%d", len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ForStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ForStatement. got=%T", program.Statements[0])
+		}
+
+		if stmt.String() != tt.expected {
+			t.Errorf("expected %q, got=%q", tt.expected, stmt.String())
+		}
+	}
+}
+
+func TestForInStatement(t *testing.T) {
+	input := `for (x in [1, 2]) { x; };`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("number of statements in program is not correct. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ForInStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ForInStatement. got=%T", program.Statements[0])
+	}
+
+	if !testIdentifier(t, stmt.Variable, "x") {
+		return
+	}
+
+	if stmt.Iterable.String() != "[1, 2]" {
+		t.Errorf("stmt.Iterable is not [1, 2]. got=%q", stmt.Iterable.String())
+	}
+
+	if len(stmt.Body.Statements) != 1 {
+		t.Fatalf("body is not 1 statement. got=%d", len(stmt.Body.Statements))
+	}
+}
+
+func TestLoopControlOutsideLoop(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"break;", "1:1: break statement outside of loop"},
+		{"if (true) { continue; }", "1:13: continue statement outside of loop"},
+		{"while (true) { func() { break; }; }", "1:25: break statement outside of loop"},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Fatalf("tests[%d] - expected 1 parser error, got=%d (%q)", i, len(errors), errors)
+		}
+
+		if errors[0].Error() != tt.expectedError {
+			t.Errorf("tests[%d] - wrong error. expected %q, got=%q", i, tt.expectedError, errors[0].Error())
+		}
+	}
+}
+
 func TestFunctionLiteralParsing(t *testing.T) {
 	input := `func(x, y) { x + y; }`
 	l := lexer.New(input)
@@ -526,6 +902,91 @@ func TestFunctionLiteralParsing(t *testing.T) {
 	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
 }
 
+func TestFunctionStatementParsing(t *testing.T) {
+	input := `func add(x, y) { x + y; }; add(1, 2);`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.FunctionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.FunctionStatement. got=%T", program.Statements[0])
+	}
+
+	if stmt.Name.Value != "add" {
+		t.Errorf("function name is not 'add'. got=%q", stmt.Name.Value)
+	}
+
+	if len(stmt.Function.Parameters) != 2 {
+		t.Fatalf("function literal parameters wrong. want 2, got=%d", len(stmt.Function.Parameters))
+	}
+
+	testLiteralExpression(t, stmt.Function.Parameters[0], "x")
+	testLiteralExpression(t, stmt.Function.Parameters[1], "y")
+
+	if stmt.String() != "func add(x, y)(x + y)" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+}
+
+func TestTypeAnnotationParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var x int = 5;", "var x int = 5;"},
+		{`var s str = "a" + "b";`, "var s str = (a + b);"},
+		{"var f = func(a int, b) float { a };", "var f = func(a int, b) floata;"},
+		{"func add(a int, b int) int { a + b }", "func add(a int, b int) int(a + b)"},
+		{"func(c char, d double, e bool) {};", "func(c char, d double, e bool)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		actual := program.String()
+		if actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestUnknownTypeAnnotation(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"var x number = 5;", "1:7: unknown type: number"},
+		{"func(a list) {};", "1:8: unknown type: list"},
+		{"func() void {};", "1:8: unknown type: void"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q", tt.input)
+			continue
+		}
+
+		if errors[0].Error() != tt.expectedError {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expectedError, errors[0].Error())
+		}
+	}
+}
+
 func TestFunctionParameterParsing(t *testing.T) {
 	tests := []struct {
 		input          string
@@ -613,14 +1074,144 @@ func TestParsingArrayLiterals(t *testing.T) {
 	testInfixExpression(t, array.Elements[2], 3, "+", 3)
 }
 
+func TestParserErrorPositions(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{"var = 5;", "test.brisk:1:5: expected next token to be IDENT, got = instead"},
+		{"var x = 5;\nvar y 5;", "test.brisk:2:7: expected next token to be =, got INT instead"},
+		{"if (x) {\n  ) }", "test.brisk:2:3: no prefix parse function for ) found"},
+		{"var x = 5; /* unterminated", "test.brisk:1:12: illegal token: unterminated block comment"},
+		{"var x = @;", "test.brisk:1:9: illegal token: @"},
+		{"var x = \"open;", "test.brisk:1:9: illegal token: unterminated string"},
+	}
+
+	for i, tt := range tests {
+		l := lexer.NewFile("test.brisk", tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Fatalf("tests[%d] - expected parser errors, got none", i)
+		}
+
+		if errors[0].Error() != tt.expectedError {
+			t.Errorf("tests[%d] - wrong error. expected %q, got=%q", i, tt.expectedError, errors[0].Error())
+		}
+	}
+}
+
+func TestParserErrorRecovery(t *testing.T) {
+	tests := []struct {
+		input              string
+		expectedErrors     []string
+		expectedStatements string
+	}{
+		{
+			"var = 5;\nvar y = 2;\nvar z 3;\ny + z;",
+			[]string{
+				"1:5: expected next token to be IDENT, got = instead",
+				"3:7: expected next token to be =, got INT instead",
+			},
+			"var y = 2;(y + z)",
+		},
+		{
+			"1 + * 2; 3 +; var a = [1, 2; var b = 4;",
+			[]string{
+				"1:5: no prefix parse function for * found",
+				"1:13: no prefix parse function for ; found",
+				"1:28: expected next token to be ], got ; instead",
+			},
+			"var b = 4;",
+		},
+		{
+			"func f(a, { return a; }\nvar ok = 1;",
+			[]string{"1:11: expected next token to be IDENT, got { instead"},
+			"var ok = 1;",
+		},
+		{
+			"if (x { y } var z = 1;",
+			[]string{"1:7: expected next token to be ), got { instead"},
+			"var z = 1;",
+		},
+		{
+			"var f = func() { var = 1; var g = 2; g };\nvar h = f();",
+			[]string{"1:22: expected next token to be IDENT, got = instead"},
+			"var f = func()var g = 2;g;var h = f();",
+		},
+	}
+
+	for i, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != len(tt.expectedErrors) {
+			t.Errorf("tests[%d] - wrong number of errors. expected=%q, got=%q", i, tt.expectedErrors, errors)
+			continue
+		}
+
+		for j, expected := range tt.expectedErrors {
+			if errors[j].Error() != expected {
+				t.Errorf("tests[%d] - wrong error. expected=%q, got=%q", i, expected, errors[j].Error())
+			}
+		}
+
+		if program.String() != tt.expectedStatements {
+			t.Errorf("tests[%d] - wrong statements after recovery. expected=%q, got=%q", i, tt.expectedStatements, program.String())
+		}
+	}
+}
+
+func TestParseErrorFields(t *testing.T) {
+	l := lexer.NewFile("test.brisk", "var x 5;\n)")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 2 {
+		t.Fatalf("expected 2 parser errors, got=%d (%q)", len(errors), errors)
+	}
+
+	expected := ParseError{
+		Pos:      token.Position{Filename: "test.brisk", Line: 1, Column: 7, Offset: 6},
+		Expected: token.VAR_EQUALS,
+		Found:    token.INT,
+		Message:  "expected next token to be =, got INT instead",
+	}
+	if *errors[0] != expected {
+		t.Errorf("wrong error. expected=%+v, got=%+v", expected, *errors[0])
+	}
+
+	if errors[1].Expected != "" || errors[1].Found != token.RIGHT_BRACKET {
+		t.Errorf("wrong tokens in error. expected found=%q, got expected=%q found=%q", token.RIGHT_BRACKET, errors[1].Expected, errors[1].Found)
+	}
+}
+
+func TestStatementsWithoutSemicolons(t *testing.T) {
+	input := "var x = 1 var y = 2 return x + y"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if program.String() != "var x = 1;var y = 2;return (x + y);" {
+		t.Errorf("wrong statements. got=%q", program.String())
+	}
+}
+
 func checkParserErrors(t *testing.T, p *Parser) {
 	errors := p.Errors()
 	if len(errors) == 0 {
 		return
 	}
 	t.Errorf("parser has %d errors", len(errors))
-	for _, msg := range errors {
-		t.Errorf("parser error: %q", msg)
+	for _, err := range errors {
+		t.Errorf("parser error: %q", err.Error())
 	}
 	t.FailNow()
 }
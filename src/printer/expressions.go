@@ -0,0 +1,278 @@
+package printer
+
+import (
+	"strings"
+
+	"github.com/kai119/Brisk/src/parser/ast"
+)
+
+// The precedences of expressions, which decide where brackets are needed. These match the
+// precedences that the parser gives to each operator
+const (
+	_ int = iota
+	LOWEST
+	ASSIGN
+	OR
+	AND
+	EQUALS
+	LESSGREATER
+	SUM
+	PRODUCT
+	PREFIX
+	CALL
+	INDEX
+	// PRIMARY is the precedence of literals and identifiers, which never need brackets
+	PRIMARY
+)
+
+var precedences = map[string]int{
+	"||": OR,
+	"&&": AND,
+	"==": EQUALS,
+	"!=": EQUALS,
+	"<":  LESSGREATER,
+	">":  LESSGREATER,
+	"<=": LESSGREATER,
+	">=": LESSGREATER,
+	"+":  SUM,
+	"-":  SUM,
+	"*":  PRODUCT,
+	"/":  PRODUCT,
+	"%":  PRODUCT,
+}
+
+func precedence(exp ast.Expression) int {
+	switch exp := exp.(type) {
+	case *ast.AssignExpression:
+		return ASSIGN
+	case *ast.InfixExpression:
+		if prec, ok := precedences[exp.Operator]; ok {
+			return prec
+		}
+		return LOWEST
+	case *ast.PrefixExpression:
+		return PREFIX
+	case *ast.CallExpression:
+		return CALL
+	case *ast.IndexExpression:
+		return INDEX
+	default:
+		return PRIMARY
+	}
+}
+
+// operand writes an expression that is part of a larger expression, putting it inside of
+// brackets if its precedence is lower than the lowest precedence that fits in that place
+func (p *printer) operand(exp ast.Expression, lowest int) {
+	if precedence(exp) < lowest {
+		p.write("(")
+		p.expression(exp)
+		p.write(")")
+		return
+	}
+	p.expression(exp)
+}
+
+// startsLikeOperator checks whether a statement starts with a token that would carry on the
+// expression before it, in which case the expression before it needs a semicolon on the end
+func startsLikeOperator(s ast.Statement) bool {
+	es, ok := s.(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+
+	exp := es.Expression
+	for {
+		switch e := exp.(type) {
+		case *ast.PrefixExpression:
+			return e.Operator == "-"
+		case *ast.ArrayLiteral:
+			return true
+		case *ast.InfixExpression:
+			if precedence(e.Left) < precedence(e) {
+				return true
+			}
+			exp = e.Left
+		case *ast.CallExpression:
+			if precedence(e.Function) < CALL {
+				return true
+			}
+			exp = e.Function
+		case *ast.IndexExpression:
+			if precedence(e.Left) < CALL {
+				return true
+			}
+			exp = e.Left
+		case *ast.AssignExpression:
+			exp = e.Target
+		default:
+			return false
+		}
+	}
+}
+
+func (p *printer) expression(exp ast.Expression) {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		p.write(exp.Value)
+	case *ast.Boolean:
+		p.write(exp.Token.Literal)
+	case *ast.IntegerLiteral:
+		p.literal(exp, exp.Token.Literal)
+	case *ast.FloatLiteral:
+		p.literal(exp, exp.Token.Literal)
+	case *ast.StringLiteral:
+		p.literal(exp, quote(exp.Value, '"'))
+	case *ast.CharLiteral:
+		p.literal(exp, quote(string(exp.Value), '\''))
+	case *ast.PrefixExpression:
+		p.write(exp.Operator)
+		// brackets keep a prefix of a prefix readable, writing -(-x) instead of --x
+		if _, ok := exp.Right.(*ast.PrefixExpression); ok {
+			p.operand(exp.Right, PRIMARY)
+		} else {
+			p.operand(exp.Right, PREFIX)
+		}
+	case *ast.InfixExpression:
+		prec := precedence(exp)
+		p.operand(exp.Left, prec)
+		p.write(" " + exp.Operator + " ")
+		p.operand(exp.Right, prec+1)
+	case *ast.AssignExpression:
+		p.expression(exp.Target)
+		p.write(" " + exp.Operator + " ")
+		p.expression(exp.Value)
+	case *ast.IfExpression:
+		p.write("if (")
+		p.expression(exp.Condition)
+		p.write(") ")
+		p.block(exp.Consequence)
+		if exp.Alternative != nil {
+			p.write(" else ")
+			p.block(exp.Alternative)
+		}
+	case *ast.FunctionLiteral:
+		p.function(exp, "")
+	case *ast.CallExpression:
+		p.operand(exp.Function, CALL)
+		p.write("(")
+		p.list(exp.Arguments)
+		p.write(")")
+	case *ast.IndexExpression:
+		p.operand(exp.Left, CALL)
+		p.write("[")
+		p.expression(exp.Index)
+		p.write("]")
+	case *ast.ArrayLiteral:
+		p.write("[")
+		p.list(exp.Elements)
+		p.write("]")
+	case *ast.TupleLiteral:
+		p.list(exp.Elements)
+	case *ast.DictionaryLiteral:
+		p.dictionary(exp)
+	}
+}
+
+// literal writes a literal the way it was written in the source code, or in its simplest
+// form if there isn't any source code
+func (p *printer) literal(exp ast.Expression, simplest string) {
+	if p.src != nil {
+		if text, ok := p.src.literals[exp.Pos().Offset]; ok {
+			p.write(text)
+			return
+		}
+	}
+	p.write(simplest)
+}
+
+func (p *printer) list(expressions []ast.Expression) {
+	for i, exp := range expressions {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.expression(exp)
+	}
+}
+
+func (p *printer) function(fn *ast.FunctionLiteral, name string) {
+	p.write("func")
+	if name != "" {
+		p.write(" " + name)
+	}
+
+	params := []string{}
+	for i, param := range fn.Parameters {
+		if i < len(fn.ParameterTypes) && fn.ParameterTypes[i] != nil {
+			params = append(params, param.Value+" "+fn.ParameterTypes[i].Name)
+		} else {
+			params = append(params, param.Value)
+		}
+	}
+	p.write("(" + strings.Join(params, ", ") + ") ")
+
+	if fn.ReturnType != nil {
+		p.write(fn.ReturnType.Name + " ")
+	}
+
+	p.block(fn.Body)
+}
+
+// dictionary writes each of the pairs in a dictionary on a line of its own, in the order
+// they were written in the source code
+func (p *printer) dictionary(dict *ast.DictionaryLiteral) {
+	end := p.closingBrace(dict.Token.Pos.Offset)
+
+	if len(dict.Pairs) == 0 && !p.hasComments(end) {
+		p.write("{}")
+		return
+	}
+
+	p.write("{")
+	p.indent++
+	p.newline()
+	p.opened = true
+
+	for _, key := range ast.SortedKeys(dict) {
+		pos := key.Pos()
+		p.flushComments(pos.Offset)
+		p.keepBlankLine(pos.Offset, pos.Line)
+
+		p.expression(key)
+		p.write(": ")
+		p.expression(dict.Pairs[key])
+		p.write(",")
+		p.newline()
+	}
+	if end >= 0 {
+		p.flushComments(end)
+	}
+
+	p.indent--
+	p.write("}")
+}
+
+var escapes = map[rune]string{
+	'\n': `\n`,
+	'\t': `\t`,
+	'\r': `\r`,
+	'\\': `\\`,
+}
+
+// quote writes a string or character literal with any characters that can't appear inside
+// of it replaced with escape sequences
+func quote(s string, delimiter rune) string {
+	var out strings.Builder
+	out.WriteRune(delimiter)
+	for _, ch := range s {
+		if escape, ok := escapes[ch]; ok {
+			out.WriteString(escape)
+		} else if ch == delimiter {
+			out.WriteString(`\` + string(ch))
+		} else {
+			out.WriteRune(ch)
+		}
+	}
+	out.WriteRune(delimiter)
+	return out.String()
+}
@@ -0,0 +1,294 @@
+// Package printer renders BRISK programs as source code in a canonical style. Blocks and
+// dictionaries are broken over several lines and indented with four spaces, operators are
+// surrounded by spaces and statements end with semicolons. When a program is printed from
+// source code, the comments, single blank lines between statements and the way that
+// literals were written are all kept. Printing code that has already been printed gives
+// back exactly the same code
+package printer
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/kai119/Brisk/src/lexer"
+	"github.com/kai119/Brisk/src/parser"
+	"github.com/kai119/Brisk/src/parser/ast"
+)
+
+// INDENT is the string used for each level of indentation
+const INDENT = "    "
+
+// SyntaxError is returned when source code can't be formatted because it doesn't parse
+type SyntaxError struct {
+	Errors []*parser.ParseError
+}
+
+func (e *SyntaxError) Error() string {
+	msgs := []string{}
+	for _, err := range e.Errors {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Source formats BRISK source code, returning a *SyntaxError if the code can't be parsed
+func Source(filename string, input string) (string, error) {
+	p := parser.New(lexer.NewFile(filename, input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return "", &SyntaxError{Errors: p.Errors()}
+	}
+
+	pr := &printer{src: newSource(filename, input)}
+	return pr.print(program), nil
+}
+
+// Program renders a program as source code. Programs that weren't parsed from source code
+// have no comments to keep, so literals are written in their simplest form
+func Program(program *ast.Program) string {
+	pr := &printer{}
+	return pr.print(program)
+}
+
+type printer struct {
+	out    bytes.Buffer
+	src    *source
+	indent int
+
+	// newlines is the number of line breaks waiting to be written before the next output,
+	// which is two when a blank line is being kept
+	newlines int
+	// opened is true when nothing has been written since the start of a block, so that
+	// blocks never start with a blank line
+	opened bool
+	// comment is the index of the next comment to be printed
+	comment int
+}
+
+func (p *printer) print(program *ast.Program) string {
+	p.statements(program.Statements)
+	p.flushComments(-1)
+
+	if p.out.Len() == 0 {
+		return ""
+	}
+	return p.out.String() + "\n"
+}
+
+// write writes the string to the output, starting a new indented line first if one is waiting
+func (p *printer) write(s string) {
+	if p.newlines > 0 && p.out.Len() > 0 {
+		p.out.WriteString(strings.Repeat("\n", p.newlines))
+		p.out.WriteString(strings.Repeat(INDENT, p.indent))
+	}
+	p.newlines = 0
+	p.opened = false
+	p.out.WriteString(s)
+}
+
+func (p *printer) newline() {
+	if p.newlines == 0 {
+		p.newlines = 1
+	}
+}
+
+// keepBlankLine keeps a blank line before the token or comment at the offset if there was
+// one in the source code
+func (p *printer) keepBlankLine(offset int, line int) {
+	if p.src == nil || p.newlines == 0 || p.opened {
+		return
+	}
+	if prev, ok := p.src.before(offset); ok && line-prev.endLine > 1 {
+		p.newlines = 2
+	}
+}
+
+// flushComments prints every comment before the offset. A comment on the same line as
+// the code before it stays at the end of that line, while other comments get lines of their
+// own. An offset of -1 prints all of the comments that are left
+func (p *printer) flushComments(offset int) {
+	if p.src == nil {
+		return
+	}
+
+	for p.comment < len(p.src.comments) {
+		c := p.src.comments[p.comment]
+		if offset >= 0 && c.tok.Pos.Offset >= offset {
+			return
+		}
+		p.comment++
+
+		prev, ok := p.src.before(c.tok.Pos.Offset)
+		if ok && prev.endLine == c.tok.Pos.Line && p.out.Len() > 0 {
+			p.out.WriteString(" " + c.text)
+		} else {
+			p.keepBlankLine(c.tok.Pos.Offset, c.tok.Pos.Line)
+			p.write(c.text)
+		}
+		p.newline()
+	}
+}
+
+// hasComments checks whether there are any comments left to print before the offset
+func (p *printer) hasComments(offset int) bool {
+	return p.src != nil && p.comment < len(p.src.comments) && p.src.comments[p.comment].tok.Pos.Offset < offset
+}
+
+// closingBrace returns the offset of the } that closes the { at the offset, or -1 if the
+// program wasn't parsed from source code
+func (p *printer) closingBrace(open int) int {
+	if p.src == nil {
+		return -1
+	}
+	if end, ok := p.src.closingBrace(open); ok {
+		return end
+	}
+	return -1
+}
+
+func (p *printer) statements(statements []ast.Statement) {
+	for i, s := range statements {
+		pos := s.Pos()
+		p.flushComments(pos.Offset)
+		p.keepBlankLine(pos.Offset, pos.Line)
+
+		p.statement(s)
+		if _, ok := s.(*ast.ExpressionStatement); ok && !needsSemicolon(s) {
+			if i+1 < len(statements) && startsLikeOperator(statements[i+1]) {
+				p.write(";")
+			}
+		}
+		p.newline()
+	}
+}
+
+// needsSemicolon checks whether a statement is written with a semicolon on the end. Loops,
+// function declarations and if expressions end with a block and don't need one
+func needsSemicolon(s ast.Statement) bool {
+	switch s := s.(type) {
+	case *ast.ExpressionStatement:
+		_, isIf := s.Expression.(*ast.IfExpression)
+		return !isIf
+	case *ast.WhileStatement, *ast.ForStatement, *ast.ForInStatement, *ast.FunctionStatement:
+		return false
+	default:
+		return true
+	}
+}
+
+func (p *printer) statement(s ast.Statement) {
+	p.simpleStatement(s)
+	if needsSemicolon(s) {
+		p.write(";")
+	}
+}
+
+// simpleStatement writes a statement without the semicolon on the end, which is how the
+// statements in the header of a for loop are written
+func (p *printer) simpleStatement(s ast.Statement) {
+	switch s := s.(type) {
+	case *ast.VarStatement:
+		p.varStatement(s)
+	case *ast.ReturnStatement:
+		p.write("return")
+		if s.ReturnValue != nil {
+			p.write(" ")
+			p.expression(s.ReturnValue)
+		}
+	case *ast.ExpressionStatement:
+		if s.Expression != nil {
+			p.expression(s.Expression)
+		}
+	case *ast.BlockStatement:
+		p.block(s)
+	case *ast.FunctionStatement:
+		p.function(s.Function, s.Name.Value)
+	case *ast.WhileStatement:
+		p.write("while (")
+		p.expression(s.Condition)
+		p.write(") ")
+		p.block(s.Body)
+	case *ast.ForStatement:
+		p.forStatement(s)
+	case *ast.ForInStatement:
+		p.write("for (" + s.Variable.Value + " in ")
+		p.expression(s.Iterable)
+		p.write(") ")
+		p.block(s.Body)
+	case *ast.BreakStatement:
+		p.write("break")
+	case *ast.ContinueStatement:
+		p.write("continue")
+	}
+}
+
+func (p *printer) varStatement(s *ast.VarStatement) {
+	p.write("var ")
+
+	names := []string{}
+	for _, name := range s.Names {
+		names = append(names, name.Value)
+	}
+	joined := strings.Join(names, ", ")
+
+	switch s.Destructure {
+	case ast.ArrayDestructure:
+		p.write("[" + joined + "]")
+	case ast.DictionaryDestructure:
+		p.write("{" + joined + "}")
+	case ast.TupleDestructure:
+		p.write(joined)
+	default:
+		p.write(s.Name.Value)
+	}
+
+	if s.Type != nil {
+		p.write(" " + s.Type.Name)
+	}
+
+	if s.Value != nil {
+		p.write(" = ")
+		p.expression(s.Value)
+	}
+}
+
+func (p *printer) forStatement(s *ast.ForStatement) {
+	p.write("for (")
+	if s.Init != nil {
+		p.simpleStatement(s.Init)
+	}
+	p.write(";")
+	if s.Condition != nil {
+		p.write(" ")
+		p.expression(s.Condition)
+	}
+	p.write(";")
+	if s.Post != nil {
+		p.write(" ")
+		p.simpleStatement(s.Post)
+	}
+	p.write(") ")
+	p.block(s.Body)
+}
+
+func (p *printer) block(b *ast.BlockStatement) {
+	end := p.closingBrace(b.Token.Pos.Offset)
+
+	if len(b.Statements) == 0 && !p.hasComments(end) {
+		p.write("{}")
+		return
+	}
+
+	p.write("{")
+	p.indent++
+	p.newline()
+	p.opened = true
+
+	p.statements(b.Statements)
+	if end >= 0 {
+		p.flushComments(end)
+	}
+
+	p.indent--
+	p.write("}")
+}
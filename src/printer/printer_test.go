@@ -0,0 +1,146 @@
+package printer
+
+import (
+	"testing"
+
+	"github.com/kai119/Brisk/src/lexer"
+	"github.com/kai119/Brisk/src/parser"
+)
+
+func TestSource(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"var x=5", "var x = 5;\n"},
+		{"var   y int=x+ 3*2;", "var y int = x + 3 * 2;\n"},
+		{"var x, y = pair(); var [a,b] = arr; var {c} = d", "var x, y = pair();\nvar [a, b] = arr;\nvar {c} = d;\n"},
+		{"(1 + 2) * 3", "(1 + 2) * 3;\n"},
+		{"1 + (2 * 3)", "1 + 2 * 3;\n"},
+		{"a - (b - c)", "a - (b - c);\n"},
+		{"(a - b) - c", "a - b - c;\n"},
+		{"!(a == b) && (c || d)", "!(a == b) && (c || d);\n"},
+		{"-(-x)", "-(-x);\n"},
+		{"(-f)(x)[0]", "(-f)(x)[0];\n"},
+		{"a=b=c+=1", "a = b = c += 1;\n"},
+		{"0x1F + 1_000 + 1.5e3", "0x1F + 1_000 + 1.5e3;\n"},
+		{`"a\tb" + '\n' + ` + "`raw`", `"a\tb" + '\n' + ` + "`raw`;\n"},
+		{"func add(a int,b) int { return a+b }", "func add(a int, b) int {\n    return a + b;\n}\n"},
+		{"var f = func() {}", "var f = func() {};\n"},
+		{"func pair() { return 1, 2 }", "func pair() {\n    return 1, 2;\n}\n"},
+		{"if(x){1}else{2}", "if (x) {\n    1;\n} else {\n    2;\n}\n"},
+		{"var y = if (x) { 1 }", "var y = if (x) {\n    1;\n};\n"},
+		{"if (x) { 1 }; -1", "if (x) {\n    1;\n};\n-1;\n"},
+		{"if (x) { 1 }; f()", "if (x) {\n    1;\n}\nf();\n"},
+		{"while(i<3){i+=1;}", "while (i < 3) {\n    i += 1;\n}\n"},
+		{
+			"for(var i=0;i<3;i+=1){if(i==1){continue;} break}",
+			"for (var i = 0; i < 3; i += 1) {\n    if (i == 1) {\n        continue;\n    }\n    break;\n}\n",
+		},
+		{"for (;;) {}", "for (;;) {}\n"},
+		{"for(c in \"abc\"){println(c)}", "for (c in \"abc\") {\n    println(c);\n}\n"},
+		{"var d = {}", "var d = {};\n"},
+		{
+			`var d = {"b": 1, "a": {"c": [1,2]}}`,
+			"var d = {\n    \"b\": 1,\n    \"a\": {\n        \"c\": [1, 2],\n    },\n};\n",
+		},
+		{"var x = 1;\n\n\n\nvar y = 2;", "var x = 1;\n\nvar y = 2;\n"},
+		{"func f() {\n\n    var x = 1;\n\n    x;\n\n}", "func f() {\n    var x = 1;\n\n    x;\n}\n"},
+		{"// comment\nvar x = 1;", "// comment\nvar x = 1;\n"},
+		{"var x = 1; // one\nvar y = 2; /* two */", "var x = 1; // one\nvar y = 2; /* two */\n"},
+		{"var x = 1;\n\n// about y\nvar y = 2;", "var x = 1;\n\n// about y\nvar y = 2;\n"},
+		{"if (x) { // why\n  y\n  // done\n}", "if (x) { // why\n    y;\n    // done\n}\n"},
+		{"while (x) {\n// nothing yet\n}", "while (x) {\n    // nothing yet\n}\n"},
+		{
+			"var d = {\n  // first\n  \"a\": 1, // one\n  \"b\": 2\n}",
+			"var d = {\n    // first\n    \"a\": 1, // one\n    \"b\": 2,\n};\n",
+		},
+		{"x;\n/* end\n   of file */", "x;\n/* end\n   of file */\n"},
+	}
+
+	for _, tt := range tests {
+		formatted, err := Source("", tt.input)
+		if err != nil {
+			t.Errorf("unexpected error formatting %q: %s", tt.input, err)
+			continue
+		}
+
+		if formatted != tt.expected {
+			t.Errorf("wrong formatting for %q.\nexpected=%q\ngot=%q", tt.input, tt.expected, formatted)
+		}
+
+		again, err := Source("", formatted)
+		if err != nil {
+			t.Errorf("unexpected error formatting %q: %s", formatted, err)
+			continue
+		}
+		if again != formatted {
+			t.Errorf("formatting is not idempotent for %q.\nfirst=%q\nsecond=%q", tt.input, formatted, again)
+		}
+	}
+}
+
+func TestSourceKeepsProgram(t *testing.T) {
+	tests := []string{
+		"var x = (1 + 2) * (3 - (4 - 5)) / -(6 % 7);",
+		"var b = !(true && false) || 1 < 2 == (3 >= 4);",
+		"f(g(1), [1, 2][0], {\"a\": 1}[\"a\"])(2);",
+		"arr[0] = d[\"k\"] -= x;",
+		"func f(n int) int { if (n < 2) { return n; } f(n - 1) + f(n - 2) }",
+		"if (x) { 1 } else { 2 } + 3;",
+		"var g = func(a, b) { return a, b; }; var p, q = g(1, 2);",
+	}
+
+	for _, input := range tests {
+		formatted, err := Source("", input)
+		if err != nil {
+			t.Errorf("unexpected error formatting %q: %s", input, err)
+			continue
+		}
+
+		if parse(t, formatted) != parse(t, input) {
+			t.Errorf("formatting %q changed the program. got=%q", input, formatted)
+		}
+	}
+}
+
+func TestSourceSyntaxError(t *testing.T) {
+	_, err := Source("test.brisk", "var = 5;")
+	if err == nil {
+		t.Fatalf("expected an error for a program that doesn't parse")
+	}
+
+	syntaxErr, ok := err.(*SyntaxError)
+	if !ok {
+		t.Fatalf("error is not *SyntaxError. got=%T", err)
+	}
+
+	expected := "test.brisk:1:5: expected next token to be IDENT, got = instead"
+	if len(syntaxErr.Errors) != 1 || syntaxErr.Error() != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, syntaxErr.Error())
+	}
+}
+
+func TestProgram(t *testing.T) {
+	l := lexer.New("var s = \"say \\\"hi\\\"\\n\"; var c = '\\''; if (s) { c } else { 0x10 }")
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors: %q", p.Errors())
+	}
+
+	expected := "var s = \"say \\\"hi\\\"\\n\";\nvar c = '\\'';\nif (s) {\n    c;\n} else {\n    0x10;\n}\n"
+	if formatted := Program(program); formatted != expected {
+		t.Errorf("wrong formatting.\nexpected=%q\ngot=%q", expected, formatted)
+	}
+}
+
+func parse(t *testing.T, input string) string {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors for %q: %q", input, p.Errors())
+	}
+	return program.String()
+}
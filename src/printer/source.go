@@ -0,0 +1,93 @@
+package printer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/kai119/Brisk/src/lexer"
+	"github.com/kai119/Brisk/src/lexer/token"
+)
+
+// item is a token or a comment in the source code, along with the text it was written as
+type item struct {
+	tok     token.Token
+	text    string
+	endLine int
+	comment bool
+}
+
+// source holds the tokens and comments of the source code that a program was parsed from, so
+// that the printer can keep comments, blank lines and the way literals were written
+type source struct {
+	items    []item
+	comments []item
+	literals map[int]string
+	closing  map[int]int
+}
+
+func newSource(filename string, input string) *source {
+	l := lexer.NewFile(filename, input)
+	l.KeepComments()
+
+	var tokens []token.Token
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		tokens = append(tokens, tok)
+	}
+
+	all := append(tokens, l.Comments()...)
+	sort.Slice(all, func(i, j int) bool { return all[i].Pos.Offset < all[j].Pos.Offset })
+
+	src := &source{
+		literals: make(map[int]string),
+		closing:  make(map[int]int),
+	}
+
+	var open []int
+	for i, tok := range all {
+		end := len(input)
+		if i+1 < len(all) {
+			end = all[i+1].Pos.Offset
+		}
+		text := strings.TrimRight(input[tok.Pos.Offset:end], " \t\r\n")
+
+		it := item{
+			tok:     tok,
+			text:    text,
+			endLine: tok.Pos.Line + strings.Count(text, "\n"),
+			comment: tok.Type == token.COMMENT,
+		}
+		src.items = append(src.items, it)
+
+		switch tok.Type {
+		case token.COMMENT:
+			src.comments = append(src.comments, it)
+		case token.INT, token.FLOAT, token.STRING, token.CHAR:
+			src.literals[tok.Pos.Offset] = text
+		case token.LEFT_CURLY_BRACKET:
+			open = append(open, tok.Pos.Offset)
+		case token.RIGHT_CURLY_BRACKET:
+			if len(open) > 0 {
+				src.closing[open[len(open)-1]] = tok.Pos.Offset
+				open = open[:len(open)-1]
+			}
+		}
+	}
+
+	return src
+}
+
+// before returns the token or comment that comes just before the offset, and false if
+// there isn't one
+func (s *source) before(offset int) (item, bool) {
+	i := sort.Search(len(s.items), func(i int) bool { return s.items[i].tok.Pos.Offset >= offset })
+	if i == 0 {
+		return item{}, false
+	}
+	return s.items[i-1], true
+}
+
+// closingBrace returns the offset of the } that matches the { at the offset
+func (s *source) closingBrace(offset int) (int, bool) {
+	end, ok := s.closing[offset]
+	return end, ok
+}
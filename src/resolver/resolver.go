@@ -0,0 +1,337 @@
+// Package resolver works out where each variable of a BRISK program is stored before the
+// program is run. Every identifier is given the number of environments out from where it is
+// used that its variable lives in, along with the variable's slot in that environment, so that
+// the evaluator can find variables by index instead of looking them up by name
+package resolver
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kai119/Brisk/src/lexer/token"
+	"github.com/kai119/Brisk/src/parser/ast"
+)
+
+// Error is an error found by the resolver, along with the position in the source code where
+// it was found
+type Error struct {
+	Pos     token.Position
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Pos.IsValid() {
+		return e.Pos.String() + ": " + e.Message
+	}
+	return e.Message
+}
+
+// Resolver fills in the bindings of the identifiers in a program. Like the evaluator's
+// environments, the program, each function call and each for loop gets its own scope, while
+// the blocks of if expressions and while loops share the scope around them
+type Resolver struct {
+	errors []*Error
+	scope  *scope
+}
+
+// scope holds the variables that are declared in one of the evaluator's environments
+type scope struct {
+	slots map[string]int
+	outer *scope
+	// later holds the variables that have been declared ahead of their declaration statement,
+	// which the scope itself can't use until the statement is reached
+	later map[string]bool
+	// function is true for the scope of a function call. The variables of the scopes around
+	// it can be used by the function however late they are declared, since the function
+	// can't be called before they are
+	function bool
+	// global is true for the scope of the program, whose variables are looked up by name so
+	// that they can be shared with the lines run after it in the REPL
+	global bool
+}
+
+// New creates a resolver for a program that can use the global variables that are given,
+// such as the builtin functions and the variables declared by earlier lines of the REPL
+func New(globals []string) *Resolver {
+	s := &scope{slots: make(map[string]int), later: make(map[string]bool), global: true}
+	for _, name := range globals {
+		s.slots[name] = -1
+	}
+	return &Resolver{scope: s}
+}
+
+// Resolve fills in the bindings of the identifiers in a program, returning all of the errors
+// it finds sorted by their position
+func Resolve(program *ast.Program, globals []string) []*Error {
+	r := New(globals)
+	r.Resolve(program)
+	return r.Errors()
+}
+
+// Resolve fills in the bindings of the identifiers in a program. The errors it finds are added
+// to the resolver's errors
+func (r *Resolver) Resolve(program *ast.Program) {
+	r.declare(program.Statements)
+	ast.Walk(r, program)
+}
+
+// Errors returns all errors that the resolver has found, sorted by their position
+func (r *Resolver) Errors() []*Error {
+	sort.SliceStable(r.errors, func(i, j int) bool {
+		a, b := r.errors[i].Pos, r.errors[j].Pos
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return r.errors
+}
+
+func (r *Resolver) addError(pos token.Position, format string, a ...interface{}) {
+	r.errors = append(r.errors, &Error{Pos: pos, Message: fmt.Sprintf(format, a...)})
+}
+
+// Visit resolves the identifiers in a node. Nodes that declare variables or start a new scope
+// are resolved here, and the children of any other node are walked by ast.Walk
+func (r *Resolver) Visit(node ast.Node) ast.Visitor {
+	switch node := node.(type) {
+	case *ast.Identifier:
+		r.resolve(node, "identifier not found: %s")
+		return nil
+	case *ast.VarStatement:
+		// the value is resolved before the variable is declared, so that it uses the
+		// variable of the same name around the scope
+		if node.Value != nil {
+			ast.Walk(r, node.Value)
+		}
+		for _, name := range declaredNames(node) {
+			r.reach(name)
+			r.resolve(name, "identifier not found: %s")
+		}
+		return nil
+	case *ast.BlockStatement:
+		// named functions are bound before the statements of their block are run
+		for _, statement := range node.Statements {
+			if fs, ok := statement.(*ast.FunctionStatement); ok {
+				r.reach(fs.Name)
+			}
+		}
+		return r
+	case *ast.FunctionStatement:
+		r.resolve(node.Name, "identifier not found: %s")
+		r.function(node.Function)
+		return nil
+	case *ast.FunctionLiteral:
+		r.function(node)
+		return nil
+	case *ast.AssignExpression:
+		if target, ok := node.Target.(*ast.Identifier); ok && node.Operator != "=" {
+			// a compound assignment reads the variable before assigning to it
+			r.resolve(target, "identifier not found: %s")
+		} else if ok {
+			r.resolve(target, "cannot assign to undeclared variable: %s")
+		} else if node.Target != nil {
+			ast.Walk(r, node.Target)
+		}
+		if node.Value != nil {
+			ast.Walk(r, node.Value)
+		}
+		return nil
+	case *ast.ForStatement:
+		r.forStatement(node)
+		return nil
+	case *ast.ForInStatement:
+		r.forInStatement(node)
+		return nil
+	}
+
+	return r
+}
+
+// function resolves a function literal, whose parameters and variables are stored in the
+// environment of each call of the function
+func (r *Resolver) function(fl *ast.FunctionLiteral) {
+	r.enterScope()
+	r.scope.function = true
+
+	params := make(map[string]bool)
+	for _, param := range fl.Parameters {
+		r.define(param, params)
+		r.resolve(param, "identifier not found: %s")
+	}
+
+	if fl.Body != nil {
+		r.declare(fl.Body.Statements)
+		ast.Walk(r, fl.Body)
+	}
+
+	fl.Slots = r.leaveScope()
+}
+
+// forStatement resolves a C-style for loop, whose init statement and body share the loop's scope
+func (r *Resolver) forStatement(fs *ast.ForStatement) {
+	r.enterScope()
+
+	if fs.Init != nil {
+		r.declare([]ast.Statement{fs.Init})
+	}
+	if fs.Body != nil {
+		r.declare(fs.Body.Statements)
+	}
+
+	if fs.Init != nil {
+		ast.Walk(r, fs.Init)
+	}
+	if fs.Condition != nil {
+		ast.Walk(r, fs.Condition)
+	}
+	if fs.Post != nil {
+		ast.Walk(r, fs.Post)
+	}
+	if fs.Body != nil {
+		ast.Walk(r, fs.Body)
+	}
+
+	fs.Slots = r.leaveScope()
+}
+
+// forInStatement resolves a loop over an iterable. The iterable is evaluated before the loop's
+// environment is made, so it is resolved in the scope around the loop
+func (r *Resolver) forInStatement(fi *ast.ForInStatement) {
+	if fi.Iterable != nil {
+		ast.Walk(r, fi.Iterable)
+	}
+
+	r.enterScope()
+
+	r.define(fi.Variable, make(map[string]bool))
+	r.resolve(fi.Variable, "identifier not found: %s")
+	if fi.Body != nil {
+		r.declare(fi.Body.Statements)
+		ast.Walk(r, fi.Body)
+	}
+
+	fi.Slots = r.leaveScope()
+}
+
+// declare defines every variable and named function declared by a list of statements before
+// any of them are resolved, so that variables can be used by functions declared before them.
+// The scope itself can only use a variable once its declaration is reached. Blocks that share
+// the scope, such as the branches of if expressions, are declared as well. A name can only be
+// declared once in each block
+func (r *Resolver) declare(statements []ast.Statement) {
+	declared := make(map[string]bool)
+
+	for _, statement := range statements {
+		switch statement := statement.(type) {
+		case *ast.FunctionStatement:
+			r.defineLater(statement.Name, declared)
+			continue
+		case *ast.VarStatement:
+			for _, name := range declaredNames(statement) {
+				r.defineLater(name, declared)
+			}
+		}
+
+		if statement == nil {
+			continue
+		}
+		ast.Inspect(statement, func(node ast.Node) bool {
+			switch node := node.(type) {
+			case *ast.FunctionLiteral, *ast.ForStatement, *ast.ForInStatement:
+				return false
+			case *ast.BlockStatement:
+				r.declare(node.Statements)
+				return false
+			}
+			return true
+		})
+	}
+}
+
+// define adds a variable to the current scope. A variable that is declared in more than one
+// block of the same scope, such as in both branches of an if expression, shares a single slot
+func (r *Resolver) define(name *ast.Identifier, declared map[string]bool) {
+	if name == nil {
+		return
+	}
+
+	if declared[name.Value] {
+		r.addError(name.Pos(), "duplicate declaration: %s", name.Value)
+		return
+	}
+	declared[name.Value] = true
+
+	if _, ok := r.scope.slots[name.Value]; ok {
+		return
+	}
+	if r.scope.global {
+		r.scope.slots[name.Value] = -1
+	} else {
+		r.scope.slots[name.Value] = len(r.scope.slots)
+	}
+}
+
+// defineLater adds a variable to the current scope ahead of its declaration statement. A
+// local variable that isn't already in the scope can't be used by the scope until the
+// statement is reached. Global variables are looked up by name, so they can always be used
+func (r *Resolver) defineLater(name *ast.Identifier, declared map[string]bool) {
+	if name == nil {
+		return
+	}
+
+	if _, ok := r.scope.slots[name.Value]; !ok && !r.scope.global {
+		r.scope.later[name.Value] = true
+	}
+	r.define(name, declared)
+}
+
+// reach marks the declaration of a variable of the current scope as reached, after which the
+// scope uses the variable
+func (r *Resolver) reach(name *ast.Identifier) {
+	if name != nil {
+		delete(r.scope.later, name.Value)
+	}
+}
+
+// resolve fills in the binding of an identifier, adding an error with the message format if
+// the variable hasn't been declared in any scope. A variable whose declaration hasn't been
+// reached yet is skipped, unless the identifier is inside of a function in its scope
+func (r *Resolver) resolve(name *ast.Identifier, format string) {
+	if name == nil {
+		return
+	}
+
+	depth := 0
+	inFunction := false
+	for s := r.scope; s != nil; s = s.outer {
+		if slot, ok := s.slots[name.Value]; ok && (inFunction || !s.later[name.Value]) {
+			name.Binding = &ast.Binding{Depth: depth, Slot: slot}
+			return
+		}
+		inFunction = inFunction || s.function
+		depth++
+	}
+
+	r.addError(name.Pos(), format, name.Value)
+}
+
+func (r *Resolver) enterScope() {
+	r.scope = &scope{slots: make(map[string]int), later: make(map[string]bool), outer: r.scope}
+}
+
+// leaveScope goes back to the scope around the current one, returning the number of slots
+// that the current scope needs
+func (r *Resolver) leaveScope() int {
+	slots := len(r.scope.slots)
+	r.scope = r.scope.outer
+	return slots
+}
+
+// declaredNames returns the names of the variables that a var statement declares
+func declaredNames(vs *ast.VarStatement) []*ast.Identifier {
+	if vs.Destructure != ast.NoDestructure {
+		return vs.Names
+	}
+	return []*ast.Identifier{vs.Name}
+}
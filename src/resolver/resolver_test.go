@@ -0,0 +1,160 @@
+package resolver
+
+import (
+	"testing"
+
+	"github.com/kai119/Brisk/src/lexer"
+	"github.com/kai119/Brisk/src/parser"
+	"github.com/kai119/Brisk/src/parser/ast"
+)
+
+func TestBindings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected map[string]ast.Binding
+	}{
+		{
+			"var x = 1; x;",
+			map[string]ast.Binding{"1:5": {Depth: 0, Slot: -1}, "1:12": {Depth: 0, Slot: -1}},
+		},
+		{
+			"func(a, b) { var c = a; b + c };",
+			map[string]ast.Binding{
+				"1:6": {Depth: 0, Slot: 0}, "1:9": {Depth: 0, Slot: 1}, "1:18": {Depth: 0, Slot: 2},
+				"1:22": {Depth: 0, Slot: 0}, "1:25": {Depth: 0, Slot: 1}, "1:29": {Depth: 0, Slot: 2},
+			},
+		},
+		{
+			"var g = 1; func(a) { func() { a + g } };",
+			map[string]ast.Binding{"1:31": {Depth: 1, Slot: 0}, "1:35": {Depth: 2, Slot: -1}},
+		},
+		{
+			"func() { for (var i = 0; i < 3; i = i + 1) { var j = i; } };",
+			map[string]ast.Binding{
+				"1:19": {Depth: 0, Slot: 0}, "1:26": {Depth: 0, Slot: 0},
+				"1:50": {Depth: 0, Slot: 1}, "1:54": {Depth: 0, Slot: 0},
+			},
+		},
+		{
+			"var arr = [1]; for (v in arr) { v };",
+			map[string]ast.Binding{"1:21": {Depth: 0, Slot: 0}, "1:26": {Depth: 0, Slot: -1}, "1:33": {Depth: 0, Slot: 0}},
+		},
+		{
+			"func() { if (true) { var x = 1 } else { var x = 2 }; x };",
+			map[string]ast.Binding{"1:26": {Depth: 0, Slot: 0}, "1:45": {Depth: 0, Slot: 0}, "1:54": {Depth: 0, Slot: 0}},
+		},
+		{
+			"func() { var f = func() { later }; var later = 1; f };",
+			map[string]ast.Binding{"1:27": {Depth: 1, Slot: 1}, "1:40": {Depth: 0, Slot: 1}},
+		},
+		{
+			"var x = 1; func() { var x = x + 1; x };",
+			map[string]ast.Binding{"1:25": {Depth: 0, Slot: 0}, "1:29": {Depth: 1, Slot: -1}, "1:36": {Depth: 0, Slot: 0}},
+		},
+		{
+			"var x = 1; func() { var r = x; var x = 5; r };",
+			map[string]ast.Binding{"1:29": {Depth: 1, Slot: -1}, "1:36": {Depth: 0, Slot: 1}, "1:43": {Depth: 0, Slot: 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		if errors := Resolve(program, nil); len(errors) != 0 {
+			t.Fatalf("resolver has errors for %q: %v", tt.input, errors)
+		}
+
+		found := 0
+		ast.Inspect(program, func(node ast.Node) bool {
+			ident, ok := node.(*ast.Identifier)
+			if !ok {
+				return true
+			}
+			expected, ok := tt.expected[ident.Pos().String()]
+			if !ok {
+				return true
+			}
+			found++
+			if ident.Binding == nil {
+				t.Errorf("identifier %s at %s in %q was not resolved", ident.Value, ident.Pos(), tt.input)
+			} else if *ident.Binding != expected {
+				t.Errorf("wrong binding for %s at %s in %q. expected=%+v, got=%+v", ident.Value, ident.Pos(), tt.input, expected, *ident.Binding)
+			}
+			return true
+		})
+
+		if found != len(tt.expected) {
+			t.Errorf("found %d of the %d identifiers expected in %q", found, len(tt.expected), tt.input)
+		}
+	}
+}
+
+func TestSlots(t *testing.T) {
+	program := parse(t, "var f = func(a) { var b = 1; for (x in [a]) { var y = x; }; if (a) { var c = 2 } };")
+	if errors := Resolve(program, nil); len(errors) != 0 {
+		t.Fatalf("resolver has errors: %v", errors)
+	}
+
+	fn := program.Statements[0].(*ast.VarStatement).Value.(*ast.FunctionLiteral)
+	if fn.Slots != 3 {
+		t.Errorf("wrong number of slots for function. expected=3, got=%d", fn.Slots)
+	}
+
+	loop := fn.Body.Statements[1].(*ast.ForInStatement)
+	if loop.Slots != 2 {
+		t.Errorf("wrong number of slots for loop. expected=2, got=%d", loop.Slots)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		globals  []string
+		expected []string
+	}{
+		{"x;", nil, []string{"1:1: identifier not found: x"}},
+		{"x;", []string{"x"}, []string{}},
+		{"if (false) { y }", nil, []string{"1:14: identifier not found: y"}},
+		{"func f() { z = 1 }", nil, []string{"1:12: cannot assign to undeclared variable: z"}},
+		{"var n = 1; n += m;", nil, []string{"1:17: identifier not found: m"}},
+		{"q += 1;", nil, []string{"1:1: identifier not found: q"}},
+		{"var a = 1; var a = 2;", nil, []string{"1:16: duplicate declaration: a"}},
+		{"func f() {} var f = 1;", nil, []string{"1:17: duplicate declaration: f"}},
+		{"func(a, a) { a };", nil, []string{"1:9: duplicate declaration: a"}},
+		{"var a, b, a = [1, 2, 3];", nil, []string{"1:11: duplicate declaration: a"}},
+		{"func() { var a = 1; while (a) { var b = 1 }; var a = 2; };", nil, []string{"1:50: duplicate declaration: a"}},
+		{"var a = 1; if (a) { var a = 2 }; for (a in [1]) { var a = 3 };", nil, []string{}},
+		{"for (i in [1]) { i }; i;", nil, []string{"1:23: identifier not found: i"}},
+		{"func() { var x = x + 1; x };", nil, []string{"1:18: identifier not found: x"}},
+		{"func() { var r = y; var y = 5; r };", nil, []string{"1:18: identifier not found: y"}},
+		{"func() { func() { y }; var y = 5 };", nil, []string{}},
+		{"missing; var x = 1; var x = 2; other;", nil, []string{
+			"1:1: identifier not found: missing",
+			"1:25: duplicate declaration: x",
+			"1:32: identifier not found: other",
+		}},
+	}
+
+	for _, tt := range tests {
+		errors := Resolve(parse(t, tt.input), tt.globals)
+		if len(errors) != len(tt.expected) {
+			t.Errorf("wrong number of errors for %q. expected=%q, got=%v", tt.input, tt.expected, errors)
+			continue
+		}
+		for i, err := range errors {
+			if err.Error() != tt.expected[i] {
+				t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected[i], err.Error())
+			}
+		}
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors for %q: %q", input, p.Errors())
+	}
+	return program
+}
@@ -0,0 +1,101 @@
+package vm
+
+import (
+	"github.com/kai119/Brisk/src/evaluator"
+	"github.com/kai119/Brisk/src/evaluator/object"
+	"github.com/kai119/Brisk/src/parser/ast"
+)
+
+// Frame is the call frame of a function that is running
+type Frame struct {
+	closure *object.Closure
+	// ip is the offset of the next instruction to run
+	ip int
+	// base is the position on the stack of the function's first local variable. The function
+	// being called sits just below it
+	base int
+	// caller is the function that made the call, and callIP is the offset of the call in it.
+	// A frame that has taken the place of another with a tail call was called from inside the
+	// function of that frame instead
+	caller *object.CompiledFunction
+	callIP int
+	// pending holds the return types of the functions whose frames this one took the place of
+	// with tail calls, which the result of the frame is checked against as well
+	pending []pendingReturn
+}
+
+// pendingReturn is the return type of a function whose result is the result of a tail call,
+// along with the call of the function
+type pendingReturn struct {
+	returnType *ast.Type
+	caller     *object.CompiledFunction
+	callIP     int
+}
+
+// check checks a returned value against the return type, giving the error the position of
+// the call
+func (p pendingReturn) check(value object.Object) *object.Error {
+	if evaluator.IsOfType(value, p.returnType.Name) {
+		return nil
+	}
+	err := newError("wrong return type: want=%s, got=%s", p.returnType, value.Type())
+	err.Pos = p.caller.Positions.Lookup(p.callIP)
+	return err
+}
+
+// addPendingReturn adds a return type to be checked once a chain of tail calls has a result.
+// Checking the same type as the last one again would give the same result, so instead only
+// the innermost call is kept for the error, as the evaluator does
+func addPendingReturn(pending []pendingReturn, p pendingReturn) []pendingReturn {
+	if last := len(pending) - 1; last >= 0 && pending[last].returnType.Name == p.returnType.Name {
+		pending[last] = p
+		return pending
+	}
+	return append(pending, p)
+}
+
+// checkReturnTypes checks the value returned by a frame against the return type of its
+// function, then against the return types of the functions that it took the place of,
+// innermost first
+func checkReturnTypes(frame *Frame, value object.Object) *object.Error {
+	if returnType := frame.closure.Fn.Literal.ReturnType; returnType != nil {
+		if err := (pendingReturn{returnType, frame.caller, frame.callIP}).check(value); err != nil {
+			return err
+		}
+	}
+	for i := len(frame.pending) - 1; i >= 0; i-- {
+		if err := frame.pending[i].check(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cell holds a local variable that closures have captured, so that the function that declared
+// the variable and the closures all see the same value
+type cell struct {
+	value object.Object
+}
+
+// Inspect returns the string representation of the object
+func (c *cell) Inspect() string {
+	if c.value == nil {
+		return "cell"
+	}
+	return "cell(" + c.value.Inspect() + ")"
+}
+
+// Type returns the type of the object
+func (c *cell) Type() object.Type { return "CELL" }
+
+// iterator holds the elements that a for-in loop has left to go through
+type iterator struct {
+	elements []object.Object
+	next     int
+}
+
+// Inspect returns the string representation of the object
+func (it *iterator) Inspect() string { return "iterator" }
+
+// Type returns the type of the object
+func (it *iterator) Type() object.Type { return "ITERATOR" }
@@ -0,0 +1,657 @@
+// Package vm runs BRISK programs that have been compiled to bytecode by the compiler package.
+// The virtual machine keeps values on a stack, with a call frame for each function that is
+// running, and gives the same results and errors as the evaluator
+package vm
+
+import (
+	"fmt"
+
+	"github.com/kai119/Brisk/src/compiler"
+	"github.com/kai119/Brisk/src/compiler/code"
+	"github.com/kai119/Brisk/src/evaluator"
+	"github.com/kai119/Brisk/src/evaluator/object"
+	"github.com/kai119/Brisk/src/parser/ast"
+)
+
+// STACK_SIZE is the number of values that the stack can hold before it has to grow
+const STACK_SIZE = 2048
+
+// The range of integers that are allocated once and shared, since small integers such as loop
+// counters are the most common results of arithmetic
+const (
+	MIN_CACHED_INT = -128
+	MAX_CACHED_INT = 1024
+)
+
+var integers [MAX_CACHED_INT - MIN_CACHED_INT + 1]*object.Integer
+
+func init() {
+	for i := range integers {
+		integers[i] = &object.Integer{Value: int64(i + MIN_CACHED_INT)}
+	}
+}
+
+var binaryOperators = map[code.Opcode]string{
+	code.OpAdd:          "+",
+	code.OpSub:          "-",
+	code.OpMul:          "*",
+	code.OpDiv:          "/",
+	code.OpMod:          "%",
+	code.OpEqual:        "==",
+	code.OpNotEqual:     "!=",
+	code.OpLessThan:     "<",
+	code.OpGreaterThan:  ">",
+	code.OpLessEqual:    "<=",
+	code.OpGreaterEqual: ">=",
+}
+
+// VM is a virtual machine that runs a compiled program
+type VM struct {
+	constants   []object.Object
+	globals     []object.Object
+	globalNames []string
+
+	stack []object.Object
+	// sp points to the next free slot of the stack, so the value on top is at stack[sp-1]
+	sp int
+
+	frames []Frame
+	// maxCallDepth is the most function calls that can be running at once
+	maxCallDepth int
+}
+
+// New creates a virtual machine that runs the compiled program
+func New(bytecode *compiler.Bytecode) *VM {
+	return NewWithGlobals(bytecode, nil)
+}
+
+// NewWithGlobals creates a virtual machine that carries on from the global variables of an
+// earlier program, so that the REPL can run one line at a time
+func NewWithGlobals(bytecode *compiler.Bytecode, globals []object.Object) *VM {
+	if len(globals) < len(bytecode.GlobalNames) {
+		grown := make([]object.Object, len(bytecode.GlobalNames))
+		copy(grown, globals)
+		globals = grown
+	}
+
+	main := bytecode.Main
+	stackSize := STACK_SIZE
+	for stackSize < len(main.LocalNames) {
+		stackSize *= 2
+	}
+
+	return &VM{
+		constants:   bytecode.Constants,
+		globals:     globals,
+		globalNames: bytecode.GlobalNames,
+		stack:       make([]object.Object, stackSize),
+		sp:          len(main.LocalNames),
+		frames:      []Frame{{closure: &object.Closure{Fn: main}}},
+
+		maxCallDepth: evaluator.DefaultMaxCallDepth,
+	}
+}
+
+// SetMaxCallDepth changes the most function calls that can be running at once, as the
+// evaluator's options do. Zero means evaluator.DefaultMaxCallDepth
+func (vm *VM) SetMaxCallDepth(depth int) {
+	if depth <= 0 {
+		depth = evaluator.DefaultMaxCallDepth
+	}
+	vm.maxCallDepth = depth
+}
+
+// Globals returns the global variables, to pass on to the virtual machine of the next program
+func (vm *VM) Globals() []object.Object {
+	return vm.globals
+}
+
+// Run runs the program, returning the value of its last statement or the error that stopped
+// it. Any Go panic that happens while running is recovered and returned as an internal error,
+// so that a bug in the virtual machine can't crash the program running it
+func (vm *VM) Run() (result object.Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = newError("internal error: %v", r)
+		}
+	}()
+
+	return vm.run()
+}
+
+func (vm *VM) run() object.Object {
+	frame := &vm.frames[len(vm.frames)-1]
+	ins := frame.closure.Fn.Instructions
+
+	for {
+		ip := frame.ip
+		op := code.Opcode(ins[ip])
+
+		switch op {
+		case code.OpConstant:
+			vm.push(vm.constants[code.ReadUint16(ins[ip+1:])])
+			frame.ip = ip + 3
+
+		case code.OpNull:
+			vm.push(evaluator.NULL)
+			frame.ip = ip + 1
+		case code.OpNil:
+			vm.push(nil)
+			frame.ip = ip + 1
+		case code.OpTrue:
+			vm.push(evaluator.TRUE)
+			frame.ip = ip + 1
+		case code.OpFalse:
+			vm.push(evaluator.FALSE)
+			frame.ip = ip + 1
+		case code.OpPop:
+			vm.sp--
+			frame.ip = ip + 1
+		case code.OpSwap:
+			vm.stack[vm.sp-1], vm.stack[vm.sp-2] = vm.stack[vm.sp-2], vm.stack[vm.sp-1]
+			frame.ip = ip + 1
+
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod,
+			code.OpEqual, code.OpNotEqual, code.OpLessThan, code.OpGreaterThan, code.OpLessEqual, code.OpGreaterEqual:
+			right := vm.stack[vm.sp-1]
+			left := vm.stack[vm.sp-2]
+			vm.sp -= 2
+
+			result := binaryOperation(op, left, right)
+			if errObj, ok := result.(*object.Error); ok {
+				return vm.fail(frame, ip, errObj)
+			}
+			vm.push(result)
+			frame.ip = ip + 1
+
+		case code.OpMinus:
+			right := vm.stack[vm.sp-1]
+			var result object.Object
+			if integer, ok := right.(*object.Integer); ok {
+				result = newInteger(-integer.Value)
+			} else {
+				result = evaluator.PrefixOperation("-", right)
+			}
+			if errObj, ok := result.(*object.Error); ok {
+				return vm.fail(frame, ip, errObj)
+			}
+			vm.stack[vm.sp-1] = result
+			frame.ip = ip + 1
+		case code.OpBang:
+			vm.stack[vm.sp-1] = evaluator.PrefixOperation("!", vm.stack[vm.sp-1])
+			frame.ip = ip + 1
+
+		case code.OpJump:
+			frame.ip = int(code.ReadUint16(ins[ip+1:]))
+		case code.OpJumpNotTruthy:
+			if evaluator.IsTruthy(vm.pop()) {
+				frame.ip = ip + 3
+			} else {
+				frame.ip = int(code.ReadUint16(ins[ip+1:]))
+			}
+		case code.OpJumpTruthy:
+			if evaluator.IsTruthy(vm.pop()) {
+				frame.ip = int(code.ReadUint16(ins[ip+1:]))
+			} else {
+				frame.ip = ip + 3
+			}
+
+		case code.OpGetGlobal:
+			idx := code.ReadUint16(ins[ip+1:])
+			value := vm.globals[idx]
+			if value == nil {
+				return vm.fail(frame, ip, newError("identifier not found: %s", vm.globalNames[idx]))
+			}
+			vm.push(value)
+			frame.ip = ip + 3
+		case code.OpSetGlobal:
+			vm.globals[code.ReadUint16(ins[ip+1:])] = vm.pop()
+			frame.ip = ip + 3
+		case code.OpAssignGlobal:
+			idx := code.ReadUint16(ins[ip+1:])
+			if vm.globals[idx] == nil {
+				return vm.fail(frame, ip, newError("cannot assign to undeclared variable: %s", vm.globalNames[idx]))
+			}
+			vm.globals[idx] = vm.stack[vm.sp-1]
+			frame.ip = ip + 3
+
+		case code.OpGetLocal:
+			idx := int(code.ReadUint16(ins[ip+1:]))
+			value := vm.stack[frame.base+idx]
+			if value == nil {
+				return vm.fail(frame, ip, newError("identifier not found: %s", frame.closure.Fn.LocalNames[idx]))
+			}
+			vm.push(value)
+			frame.ip = ip + 3
+		case code.OpSetLocal:
+			vm.stack[frame.base+int(code.ReadUint16(ins[ip+1:]))] = vm.pop()
+			frame.ip = ip + 3
+		case code.OpAssignLocal:
+			idx := int(code.ReadUint16(ins[ip+1:]))
+			if vm.stack[frame.base+idx] == nil {
+				return vm.fail(frame, ip, newError("cannot assign to undeclared variable: %s", frame.closure.Fn.LocalNames[idx]))
+			}
+			vm.stack[frame.base+idx] = vm.stack[vm.sp-1]
+			frame.ip = ip + 3
+		case code.OpClearLocal:
+			vm.stack[frame.base+int(code.ReadUint16(ins[ip+1:]))] = nil
+			frame.ip = ip + 3
+
+		case code.OpGetCell:
+			idx := int(code.ReadUint16(ins[ip+1:]))
+			c, _ := vm.stack[frame.base+idx].(*cell)
+			if c == nil || c.value == nil {
+				return vm.fail(frame, ip, newError("identifier not found: %s", frame.closure.Fn.LocalNames[idx]))
+			}
+			vm.push(c.value)
+			frame.ip = ip + 3
+		case code.OpSetCell:
+			slot := frame.base + int(code.ReadUint16(ins[ip+1:]))
+			value := vm.pop()
+			if c, ok := vm.stack[slot].(*cell); ok {
+				c.value = value
+			} else {
+				vm.stack[slot] = &cell{value: value}
+			}
+			frame.ip = ip + 3
+		case code.OpAssignCell:
+			idx := int(code.ReadUint16(ins[ip+1:]))
+			c, _ := vm.stack[frame.base+idx].(*cell)
+			if c == nil || c.value == nil {
+				return vm.fail(frame, ip, newError("cannot assign to undeclared variable: %s", frame.closure.Fn.LocalNames[idx]))
+			}
+			c.value = vm.stack[vm.sp-1]
+			frame.ip = ip + 3
+		case code.OpLoadCell:
+			slot := frame.base + int(code.ReadUint16(ins[ip+1:]))
+			c, ok := vm.stack[slot].(*cell)
+			if !ok {
+				// the closure is made before the variable is declared, for example by a
+				// function declaration that calls itself
+				c = &cell{}
+				vm.stack[slot] = c
+			}
+			vm.push(c)
+			frame.ip = ip + 3
+		case code.OpBox:
+			slot := frame.base + int(code.ReadUint16(ins[ip+1:]))
+			vm.stack[slot] = &cell{value: vm.stack[slot]}
+			frame.ip = ip + 3
+
+		case code.OpGetFree:
+			idx := code.ReadUint16(ins[ip+1:])
+			c := frame.closure.Free[idx].(*cell)
+			if c.value == nil {
+				return vm.fail(frame, ip, newError("identifier not found: %s", frame.closure.Fn.FreeNames[idx]))
+			}
+			vm.push(c.value)
+			frame.ip = ip + 3
+		case code.OpAssignFree:
+			idx := code.ReadUint16(ins[ip+1:])
+			c := frame.closure.Free[idx].(*cell)
+			if c.value == nil {
+				return vm.fail(frame, ip, newError("cannot assign to undeclared variable: %s", frame.closure.Fn.FreeNames[idx]))
+			}
+			c.value = vm.stack[vm.sp-1]
+			frame.ip = ip + 3
+		case code.OpLoadFree:
+			vm.push(frame.closure.Free[code.ReadUint16(ins[ip+1:])])
+			frame.ip = ip + 3
+
+		case code.OpArray:
+			vm.push(&object.Array{Elements: vm.popValues(int(code.ReadUint16(ins[ip+1:])))})
+			frame.ip = ip + 3
+		case code.OpTuple:
+			vm.push(&object.Tuple{Elements: vm.popValues(int(code.ReadUint16(ins[ip+1:])))})
+			frame.ip = ip + 3
+		case code.OpDictionary:
+			dict, err := buildDictionary(vm.popValues(int(code.ReadUint16(ins[ip+1:]))))
+			if err != nil {
+				return vm.fail(frame, ip, err)
+			}
+			vm.push(dict)
+			frame.ip = ip + 3
+		case code.OpIndex:
+			index := vm.pop()
+			left := vm.pop()
+			result := evaluator.IndexOperation(left, index)
+			if errObj, ok := result.(*object.Error); ok {
+				return vm.fail(frame, ip, errObj)
+			}
+			vm.push(result)
+			frame.ip = ip + 1
+		case code.OpSetIndex:
+			index := vm.pop()
+			left := vm.pop()
+			value := vm.pop()
+			result := setIndex(code.Operators[ins[ip+1]], left, index, value)
+			if errObj, ok := result.(*object.Error); ok {
+				return vm.fail(frame, ip, errObj)
+			}
+			vm.push(result)
+			frame.ip = ip + 2
+
+		case code.OpCall, code.OpTailCall:
+			numArgs := int(ins[ip+1])
+			frame.ip = ip + 2
+
+			switch fn := vm.stack[vm.sp-1-numArgs].(type) {
+			case *object.Closure:
+				var err *object.Error
+				if op == code.OpTailCall {
+					err = vm.replaceFrame(fn, numArgs, ip)
+				} else {
+					err = vm.pushFrame(fn, numArgs, ip)
+				}
+				if err != nil {
+					return vm.fail(frame, ip, err)
+				}
+				frame = &vm.frames[len(vm.frames)-1]
+				ins = fn.Fn.Instructions
+			case *object.Builtin:
+				args := vm.popValues(numArgs)
+				vm.sp--
+				result := fn.Fn(args...)
+				if errObj, ok := result.(*object.Error); ok {
+					return vm.fail(frame, ip, errObj)
+				}
+				vm.push(result)
+			default:
+				return vm.fail(frame, ip, newError("not a function: %s", fn.Type()))
+			}
+		case code.OpReturnValue:
+			value := vm.pop()
+			if len(vm.frames) == 1 {
+				return value
+			}
+			// a function that ends in a statement with no value results in null
+			if value == nil {
+				value = evaluator.NULL
+			}
+
+			if err := checkReturnTypes(frame, value); err != nil {
+				return err
+			}
+
+			vm.sp = frame.base - 1
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			frame = &vm.frames[len(vm.frames)-1]
+			ins = frame.closure.Fn.Instructions
+			vm.push(value)
+		case code.OpClosure:
+			fn := vm.constants[code.ReadUint16(ins[ip+1:])].(*object.CompiledFunction)
+			free := vm.popValues(int(code.ReadUint16(ins[ip+3:])))
+			vm.push(&object.Closure{Fn: fn, Free: free})
+			frame.ip = ip + 5
+
+		case code.OpCheckType:
+			name := vm.constants[code.ReadUint16(ins[ip+1:])].(*object.String).Value
+			typeName := vm.constants[code.ReadUint16(ins[ip+3:])].(*object.String).Value
+			value := vm.stack[vm.sp-1]
+			if !evaluator.IsOfType(value, typeName) {
+				return vm.fail(frame, ip, newError("wrong type for variable %s: want=%s, got=%s", name, typeName, value.Type()))
+			}
+			frame.ip = ip + 5
+		case code.OpDestructure:
+			kind := ast.Destructure(ins[ip+1])
+			namesArray := vm.constants[code.ReadUint16(ins[ip+2:])].(*object.Array)
+			names := make([]string, len(namesArray.Elements))
+			for i, name := range namesArray.Elements {
+				names[i] = name.(*object.String).Value
+			}
+
+			values, err := evaluator.Unpack(kind, names, vm.pop())
+			if err != nil {
+				return vm.fail(frame, ip, err)
+			}
+			for i := len(values) - 1; i >= 0; i-- {
+				vm.push(values[i])
+			}
+			frame.ip = ip + 4
+
+		case code.OpIter:
+			elements, err := evaluator.Elements(vm.pop())
+			if err != nil {
+				return vm.fail(frame, ip, err)
+			}
+			vm.push(&iterator{elements: elements})
+			frame.ip = ip + 1
+		case code.OpIterNext:
+			it := vm.stack[frame.base+int(code.ReadUint16(ins[ip+1:]))].(*iterator)
+			if it.next >= len(it.elements) {
+				frame.ip = int(code.ReadUint16(ins[ip+3:]))
+				continue
+			}
+			vm.push(it.elements[it.next])
+			it.next++
+			frame.ip = ip + 5
+
+		default:
+			return newError("unknown opcode: %d", op)
+		}
+	}
+}
+
+// pushFrame starts a call of a closure whose arguments are on top of the stack, made by the
+// instruction at the offset in the current frame. The arguments become the first local
+// variables of the new frame, and the rest of its variables are cleared
+func (vm *VM) pushFrame(cl *object.Closure, numArgs int, callIP int) *object.Error {
+	if len(vm.frames)-1 >= vm.maxCallDepth {
+		return vm.callDepthError()
+	}
+	if err := checkArguments(cl.Fn, vm.stack[vm.sp-numArgs:vm.sp]); err != nil {
+		return err
+	}
+
+	base := vm.sp - numArgs
+	vm.startFrame(cl, base, numArgs)
+	vm.frames = append(vm.frames, Frame{
+		closure: cl,
+		base:    base,
+		caller:  vm.frames[len(vm.frames)-1].closure.Fn,
+		callIP:  callIP,
+	})
+	return nil
+}
+
+// replaceFrame starts a tail call of a closure whose arguments are on top of the stack, made
+// by the instruction at the offset in the current frame. The closure and its arguments are
+// moved down to take the place of the current frame, so that recursion through tail calls
+// doesn't add to the call depth. The return type of the current function is checked once the
+// call has a result
+func (vm *VM) replaceFrame(cl *object.Closure, numArgs int, callIP int) *object.Error {
+	if err := checkArguments(cl.Fn, vm.stack[vm.sp-numArgs:vm.sp]); err != nil {
+		return err
+	}
+
+	frame := &vm.frames[len(vm.frames)-1]
+	pending := frame.pending
+	if returnType := frame.closure.Fn.Literal.ReturnType; returnType != nil {
+		pending = addPendingReturn(pending, pendingReturn{returnType, frame.caller, frame.callIP})
+	}
+
+	copy(vm.stack[frame.base-1:], vm.stack[vm.sp-1-numArgs:vm.sp])
+	vm.startFrame(cl, frame.base, numArgs)
+	*frame = Frame{
+		closure: cl,
+		base:    frame.base,
+		caller:  frame.closure.Fn,
+		callIP:  callIP,
+		pending: pending,
+	}
+	return nil
+}
+
+// startFrame clears the local variables of a function after its arguments, which start at
+// the base of the stack, and moves the top of the stack past them
+func (vm *VM) startFrame(cl *object.Closure, base int, numArgs int) {
+	top := base + len(cl.Fn.LocalNames)
+	vm.grow(top)
+	for i := base + numArgs; i < top; i++ {
+		vm.stack[i] = nil
+	}
+	vm.sp = top
+}
+
+// checkArguments checks the number of arguments to a function and their types
+func checkArguments(fn *object.CompiledFunction, args []object.Object) *object.Error {
+	if len(args) != fn.NumParameters {
+		return newError("wrong number of arguments: want=%d, got=%d", fn.NumParameters, len(args))
+	}
+
+	lit := fn.Literal
+	for i, paramType := range lit.ParameterTypes {
+		if i < len(args) && paramType != nil && !evaluator.IsOfType(args[i], paramType.Name) {
+			return newError("wrong type for parameter %s: want=%s, got=%s", lit.Parameters[i].Value, paramType, args[i].Type())
+		}
+	}
+	return nil
+}
+
+// callDepthError reports that the maximum call depth has been exceeded, listing the most
+// recent calls in the same way as the evaluator
+func (vm *VM) callDepthError() *object.Error {
+	callStack := make([]evaluator.CallFrame, 0, len(vm.frames)-1)
+	for _, frame := range vm.frames[1:] {
+		callStack = append(callStack, evaluator.CallFrame{
+			Name: frame.closure.Fn.Literal.Name,
+			Pos:  frame.caller.Positions.Lookup(frame.callIP),
+		})
+	}
+	return evaluator.CallDepthError(vm.maxCallDepth, callStack)
+}
+
+func (vm *VM) push(obj object.Object) {
+	if vm.sp >= len(vm.stack) {
+		vm.grow(vm.sp + 1)
+	}
+	vm.stack[vm.sp] = obj
+	vm.sp++
+}
+
+func (vm *VM) pop() object.Object {
+	vm.sp--
+	return vm.stack[vm.sp]
+}
+
+// popValues takes the number of values off the top of the stack, keeping them in order
+func (vm *VM) popValues(n int) []object.Object {
+	values := make([]object.Object, n)
+	copy(values, vm.stack[vm.sp-n:vm.sp])
+	vm.sp -= n
+	return values
+}
+
+// grow makes the stack big enough to hold the number of values
+func (vm *VM) grow(size int) {
+	if size <= len(vm.stack) {
+		return
+	}
+
+	newSize := len(vm.stack) * 2
+	for newSize < size {
+		newSize *= 2
+	}
+	stack := make([]object.Object, newSize)
+	copy(stack, vm.stack)
+	vm.stack = stack
+}
+
+// fail gives an error the position of the instruction at the offset in the frame, if it doesn't
+// already have a position
+func (vm *VM) fail(frame *Frame, offset int, err *object.Error) *object.Error {
+	if !err.Pos.IsValid() {
+		err.Pos = frame.closure.Fn.Positions.Lookup(offset)
+	}
+	return err
+}
+
+// binaryOperation applies a binary operator, working out the result of integer arithmetic
+// directly and leaving everything else to the evaluator
+func binaryOperation(op code.Opcode, left object.Object, right object.Object) object.Object {
+	if l, ok := left.(*object.Integer); ok {
+		if r, ok := right.(*object.Integer); ok {
+			switch op {
+			case code.OpAdd:
+				return newInteger(l.Value + r.Value)
+			case code.OpSub:
+				return newInteger(l.Value - r.Value)
+			case code.OpMul:
+				return newInteger(l.Value * r.Value)
+			case code.OpDiv:
+				if r.Value != 0 {
+					return newInteger(l.Value / r.Value)
+				}
+			case code.OpMod:
+				if r.Value != 0 {
+					return newInteger(l.Value % r.Value)
+				}
+			case code.OpEqual:
+				return nativeBoolToBooleanObj(l.Value == r.Value)
+			case code.OpNotEqual:
+				return nativeBoolToBooleanObj(l.Value != r.Value)
+			case code.OpLessThan:
+				return nativeBoolToBooleanObj(l.Value < r.Value)
+			case code.OpGreaterThan:
+				return nativeBoolToBooleanObj(l.Value > r.Value)
+			case code.OpLessEqual:
+				return nativeBoolToBooleanObj(l.Value <= r.Value)
+			case code.OpGreaterEqual:
+				return nativeBoolToBooleanObj(l.Value >= r.Value)
+			}
+		}
+	}
+
+	return evaluator.InfixOperation(binaryOperators[op], left, right)
+}
+
+// setIndex assigns a value to an index of an array or dictionary. A compound assignment
+// operator is applied to the current value at the index first
+func setIndex(operator string, left object.Object, index object.Object, value object.Object) object.Object {
+	if operator != "" {
+		current := evaluator.IndexOperation(left, index)
+		if _, ok := current.(*object.Error); ok {
+			return current
+		}
+		value = evaluator.InfixOperation(operator, current, value)
+		if _, ok := value.(*object.Error); ok {
+			return value
+		}
+	}
+
+	return evaluator.IndexAssignment(left, index, value)
+}
+
+// buildDictionary makes a dictionary from a list of keys, each followed by its value
+func buildDictionary(values []object.Object) (object.Object, *object.Error) {
+	pairs := make(map[object.DictionaryKey]object.DictionaryPair, len(values)/2)
+
+	for i := 0; i < len(values); i += 2 {
+		key := values[i]
+		dictKey, ok := key.(object.Hashable)
+		if !ok {
+			return nil, newError("unusable as hash key: %s", key.Type())
+		}
+		pairs[dictKey.DictionaryKey()] = object.DictionaryPair{Key: key, Value: values[i+1]}
+	}
+
+	return &object.Dictionary{Pairs: pairs}, nil
+}
+
+func newInteger(value int64) *object.Integer {
+	if value >= MIN_CACHED_INT && value <= MAX_CACHED_INT {
+		return integers[value-MIN_CACHED_INT]
+	}
+	return &object.Integer{Value: value}
+}
+
+func nativeBoolToBooleanObj(input bool) *object.Boolean {
+	if input {
+		return evaluator.TRUE
+	}
+	return evaluator.FALSE
+}
+
+func newError(format string, a ...interface{}) *object.Error {
+	return &object.Error{Message: fmt.Sprintf(format, a...)}
+}
@@ -0,0 +1,123 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/kai119/Brisk/src/compiler"
+	"github.com/kai119/Brisk/src/evaluator/object"
+	"github.com/kai119/Brisk/src/lexer"
+	"github.com/kai119/Brisk/src/parser"
+	"github.com/kai119/Brisk/src/parser/ast"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + 2 * 3", "7"},
+		{"var x = 0; for (var i = 0; i < 5; i += 1) { x += i }; x", "10"},
+		{"func fib(n) { if (n < 2) { return n }; fib(n - 1) + fib(n - 2) }; fib(15)", "610"},
+		{"var makeCounter = func() { var c = 0; func() { c += 1; c } }; var inc = makeCounter(); inc(); inc()", "2"},
+		{"var total = 0; for (v in [1, 2, 3]) { if (v == 2) { continue }; total += v }; total", "4"},
+		{`var d = {"a": 1}; d["a"] += 2; d["a"]`, "3"},
+		{"func pair() { return 1, 2; } var a, b = pair(); a + b", "3"},
+		{"func() { 1 }()", "1"},
+		{"while (false) { 1 }", "null"},
+		{"var x = 1;", "<nil>"},
+		{"func sum(n int, acc int) int { if (n == 0) { return acc } sum(n - 1, acc + n) }; sum(100000, 0)", "5000050000"},
+	}
+
+	for _, tt := range tests {
+		result := run(t, tt.input)
+		if inspect(result) != tt.expected {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", tt.input, tt.expected, inspect(result))
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 + true", "1:1: type mismatch: INTEGER + BOOLEAN"},
+		{"var f = func() {\n  x\n};\nf()", "2:3: identifier not found: x"},
+		{"var f = func(a int) { a };\nf(true)", "2:1: wrong type for parameter a: want=int, got=BOOLEAN"},
+		{"func f() { 1 / 0 }\nf()", "1:12: division by zero"},
+		{"y = 1", "1:1: cannot assign to undeclared variable: y"},
+		{"func f(n) { if (n == 0) { return 0 }; 1 + f(n - 1) }\nf(20000)", "1:43: maximum call depth 10000 exceeded\n" +
+			"    at f (1:43)\n    at f (1:43)\n    at f (1:43)\n    at f (1:43)\n    at f (1:43)\n    ... 9995 more calls"},
+	}
+
+	for _, tt := range tests {
+		result := run(t, tt.input)
+		err, ok := result.(*object.Error)
+		if !ok {
+			t.Errorf("no error returned for %q. got=%s", tt.input, inspect(result))
+			continue
+		}
+		if err.String() != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected, err.String())
+		}
+	}
+}
+
+func TestGlobalsAcrossPrograms(t *testing.T) {
+	symbols := compiler.NewSymbolTable()
+	constants := []object.Object{}
+	var globals []object.Object
+
+	inputs := []string{"var x = 5;", "var double = func(n) { n * 2 };", "double(x) + y", "var y = 1;", "double(x) + y"}
+	expected := []string{"<nil>", "<nil>", "ERROR: 1:13: identifier not found: y", "<nil>", "11"}
+
+	for i, input := range inputs {
+		c := compiler.NewWithState(symbols, constants)
+		err := c.Compile(parse(t, input))
+
+		bytecode := c.Bytecode()
+		constants = bytecode.Constants
+
+		var result object.Object
+		if err != nil {
+			result = &object.Error{Message: err.Error()}
+		} else {
+			machine := NewWithGlobals(bytecode, globals)
+			result = machine.Run()
+			globals = machine.Globals()
+		}
+
+		if inspect(result) != expected[i] {
+			t.Errorf("wrong result for %q. expected=%s, got=%s", input, expected[i], inspect(result))
+		}
+	}
+}
+
+func run(t *testing.T, input string) object.Object {
+	t.Helper()
+
+	c := compiler.New()
+	if err := c.Compile(parse(t, input)); err != nil {
+		// errors found before the program is run are returned in the same way as the REPL does
+		return &object.Error{Message: err.Error()}
+	}
+	return New(c.Bytecode()).Run()
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser has errors for %q: %q", input, p.Errors())
+	}
+	return program
+}
+
+func inspect(obj object.Object) string {
+	if obj == nil {
+		return "<nil>"
+	}
+	return obj.Inspect()
+}